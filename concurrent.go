@@ -0,0 +1,283 @@
+package findup
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// levelOutcome is the result of running a matcher on one directory level
+type levelOutcome struct {
+	done    bool
+	path    string
+	matched bool
+	err     error
+}
+
+// findUpWithMatcherAnyInDir runs matcher on the directories from dir up to stopAt with at most
+// options.Concurrency running at once, and returns the match of the nearest directory that matched
+func findUpWithMatcherAnyInDir(dir string, matcher MatcherFunc, options *Options, stopAt string) (string, error) {
+	var dirs []string
+	err := walkUp(dir, stopAt, options, func(current string, depth int) (bool, error) {
+		dirs = append(dirs, current)
+		return false, nil
+	})
+	if err != nil || len(dirs) == 0 {
+		return "", err
+	}
+
+	limit := options.Concurrency
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		outcomes  = make([]levelOutcome, len(dirs))
+		decided   = make(chan struct{})
+		isDecided bool
+		result    string
+		resultErr error
+	)
+
+	// decide settles the result once every directory below the nearest match or error has finished,
+	// it must be called with mu held
+	decide := func() {
+		if isDecided {
+			return
+		}
+		for depth, outcome := range outcomes {
+			if !outcome.done {
+				return
+			}
+			if outcome.err != nil {
+				resultErr = outcome.err
+				break
+			}
+			if outcome.matched {
+				foundMatch(options, outcome.path, depth)
+				result = outcome.path
+				break
+			}
+		}
+		isDecided = true
+		close(decided)
+	}
+
+	sem := make(chan struct{}, limit)
+dispatch:
+	for i, current := range dirs {
+		select {
+		case sem <- struct{}{}:
+		case <-decided:
+			break dispatch
+		}
+
+		wg.Add(1)
+		go func(i int, current string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Skip directories that can no longer change the result
+			select {
+			case <-decided:
+				return
+			default:
+			}
+
+			outcome, err := withLevelTimeout(options, func() (matcherResult, error) {
+				matched, shouldStop, err := callMatcher(matcher, current, options.MatcherCache)
+				return matcherResult{path: matched, shouldStop: shouldStop}, err
+			})
+			if err == ErrLevelTimeout {
+				_, err = skipDir(options, current, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			outcomes[i] = levelOutcome{done: true, path: outcome.path, matched: outcome.shouldStop, err: err}
+			decide()
+		}(i, current)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return result, resultErr
+}
+
+// walkDownMultipleConcurrent searches the tree below root with options.DownConcurrency directories searched
+// at once, appending matches to results. Once Limit or MaxResults is reached no more matches are kept
+// and no more directories are searched.
+func walkDownMultipleConcurrent(root walkFrame, name string, options *Options, results *[]EntryMatch) error {
+	_, err := collectMatches(options, results, func(emit func(EntryMatch) (bool, bool)) ([]walkFrame, error) {
+		return nil, walkDownConcurrent(root, name, options, emit)
+	})
+	return err
+}
+
+// queuedFrame is a directory waiting to be searched by walkDownConcurrent along with its position in the
+// order walkDown would search it, given by the indexes of the subdirectories leading to it from the root
+type queuedFrame struct {
+	walkFrame
+	order []int
+}
+
+// frameError is the error found searching the directory at order
+type frameError struct {
+	order []int
+	err   error
+}
+
+// walkDownConcurrent searches the tree below root with options.DownConcurrency workers taking directories
+// from a shared queue, passing each match to emit, one at a time. When emit returns false the workers stop
+// as soon as they finish their current directory. Unless errors are collected, a failed directory only ends
+// the walk for the directories after it in the order walkDown would search them, so the error returned is
+// the one walkDown would return whichever worker fails first.
+func walkDownConcurrent(root walkFrame, name string, options *Options, emit func(EntryMatch) (bool, bool)) error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+	)
+
+	// queue holds the directories waiting to be searched and pending counts them along with
+	// the ones being searched, so the workers know the walk is over once it drops to zero
+	var (
+		queueMu sync.Mutex
+		ready   = sync.NewCond(&queueMu)
+		queue   = []queuedFrame{{walkFrame: root}}
+		pending = 1
+		errs    []frameError
+		cut     bool
+		cutoff  []int
+	)
+
+	// cutBefore reports whether the walk ended at a directory at or before order
+	cutBefore := func(order []int) bool {
+		return cut && slices.Compare(cutoff, order) <= 0
+	}
+
+	worker := func() {
+		defer wg.Done()
+
+		for {
+			queueMu.Lock()
+			for len(queue) == 0 && pending > 0 && !stopped.Load() {
+				ready.Wait()
+			}
+			if len(queue) == 0 || stopped.Load() {
+				queueMu.Unlock()
+				ready.Broadcast()
+				return
+			}
+			frame := queue[0]
+			queue = queue[1:]
+			skip := cutBefore(frame.order)
+			queueMu.Unlock()
+
+			var subdirs []string
+			var err error
+			if !skip {
+				subdirs, err = searchFrame(frame.walkFrame, name, options, &stopped, &mu, emit)
+			}
+
+			queueMu.Lock()
+			if err != nil {
+				errs = append(errs, frameError{order: frame.order, err: err})
+
+				// Abort even when errors are collected
+				if (!options.CollectErrors || errors.Is(err, ErrAborted)) && !cutBefore(frame.order) {
+					cut, cutoff = true, frame.order
+				}
+			}
+			for i, subdir := range subdirs {
+				next := walkFrame{Dir: subdir, Depth: frame.Depth + 1}
+				queue = append(queue, queuedFrame{walkFrame: next, order: append(slices.Clip(frame.order), i)})
+			}
+			pending += len(subdirs) - 1
+			queueMu.Unlock()
+			ready.Broadcast()
+		}
+	}
+
+	for i := 0; i < max(options.DownConcurrency, 1); i++ {
+		wg.Add(1)
+		go worker()
+	}
+	wg.Wait()
+
+	// Report the errors in walk order, leaving out those after the directory that ended the walk
+	slices.SortFunc(errs, func(a, b frameError) int {
+		return slices.Compare(a.order, b.order)
+	})
+	var ordered []error
+	for _, failed := range errs {
+		if cut && slices.Compare(failed.order, cutoff) > 0 {
+			break
+		}
+		ordered = append(ordered, failed.err)
+	}
+
+	if !options.CollectErrors && len(ordered) > 0 {
+		return ordered[0]
+	}
+	return errors.Join(ordered...)
+}
+
+// searchFrame emits the matches in the directory of frame under mu and returns its subdirectories,
+// or nil when the walk stopped, along with the error when the directory could not be read
+func searchFrame(frame walkFrame, name string, options *Options, stopped *atomic.Bool, mu *sync.Mutex, emit func(EntryMatch) (bool, bool)) ([]string, error) {
+	if stopped.Load() {
+		return nil, nil
+	}
+
+	// Check if we've exceeded the depth limit
+	if options.Depth >= 0 && frame.Depth > options.Depth {
+		reportPrune(options, frame.Dir, frame.Depth, "depth")
+		return nil, nil
+	}
+	enterDir(options, frame.Dir, frame.Depth)
+	if err := checkAbort(options, frame.Dir); err != nil {
+		return nil, err
+	}
+
+	var matches []EntryMatch
+	if frame.Depth >= options.MinDepth {
+		matches = findEntriesInDir(frame.Dir, name, options)
+		if frame.Root && options.IncludeSelf {
+			if match, ok := selfMatch(frame.Dir, name, options); ok {
+				matches = append([]EntryMatch{match}, matches...)
+			}
+		}
+	}
+	if options.PerDirLimit > 0 && len(matches) > options.PerDirLimit {
+		matches = matches[:options.PerDirLimit]
+	}
+
+	mu.Lock()
+	for _, match := range matches {
+		// Another worker may have reached the limit while this directory was searched
+		if stopped.Load() {
+			break
+		}
+		foundMatch(options, match.Path, frame.Depth)
+		match.Depth = frame.Depth
+		if _, more := emit(match); !more {
+			stopped.Store(true)
+		}
+	}
+	mu.Unlock()
+	if stopped.Load() {
+		return nil, nil
+	}
+
+	entries, err := readDir(options, frame.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return collectSubdirs(frame.Dir, entries, options, frame.Depth+1), nil
+}
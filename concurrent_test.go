@@ -0,0 +1,276 @@
+package findup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFindUpWithMatcherAny(t *testing.T) {
+	// tempDir/
+	//   ├── marker
+	//   └── dir1/
+	//       ├── marker
+	//       └── dir2/
+	//           └── dir3/
+	//               └── dir4/
+	tempDir := createTestTree(t, "marker", "dir1/marker", "dir1/dir2/dir3/dir4/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2", "dir3", "dir4")
+
+	hasMarker := func(directory string) (string, bool, error) {
+		// Make the nearest directories the slowest so farther matches finish first
+		time.Sleep(time.Duration(len(directory)) * time.Microsecond * 50)
+		target := filepath.Join(directory, "marker")
+		if _, err := os.Stat(target); err == nil {
+			return target, true, nil
+		}
+		return "", false, nil
+	}
+
+	t.Run("nearest match wins", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), Concurrency: 4}
+		result, err := FindUpWithMatcherAny(hasMarker, options)
+		if err != nil {
+			t.Fatalf("FindUpWithMatcherAny failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "dir1", "marker")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		result, err := FindUpWithMatcherAny(func(directory string) (string, bool, error) {
+			return "", false, nil
+		}, options)
+		if err != nil {
+			t.Fatalf("FindUpWithMatcherAny failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("concurrency is bounded", func(t *testing.T) {
+		var running, peak int64
+		matcher := func(directory string) (string, bool, error) {
+			current := atomic.AddInt64(&running, 1)
+			for {
+				old := atomic.LoadInt64(&peak)
+				if current <= old || atomic.CompareAndSwapInt64(&peak, old, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt64(&running, -1)
+			return "", false, nil
+		}
+
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), Concurrency: 2}
+		if _, err := FindUpWithMatcherAny(matcher, options); err != nil {
+			t.Fatalf("FindUpWithMatcherAny failed: %v", err)
+		}
+		if peak > 2 {
+			t.Errorf("Expected at most 2 concurrent matchers, got %d", peak)
+		}
+	})
+
+	t.Run("pending matchers are skipped after a match", func(t *testing.T) {
+		var calls int64
+		matcher := func(directory string) (string, bool, error) {
+			atomic.AddInt64(&calls, 1)
+			return directory, true, nil
+		}
+
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), Concurrency: 1}
+		result, err := FindUpWithMatcherAny(matcher, options)
+		if err != nil {
+			t.Fatalf("FindUpWithMatcherAny failed: %v", err)
+		}
+		if result != cwd {
+			t.Errorf("Expected %s, got %s", cwd, result)
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 matcher call, got %d", calls)
+		}
+	})
+
+	t.Run("error below the nearest match", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		matcher := func(directory string) (string, bool, error) {
+			if directory == cwd {
+				return "", false, errBoom
+			}
+			return directory, true, nil
+		}
+
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		if _, err := FindUpWithMatcherAny(matcher, options); !errors.Is(err, errBoom) {
+			t.Errorf("Expected errBoom, got %v", err)
+		}
+	})
+}
+
+func TestFindDownMultipleConcurrent(t *testing.T) {
+	// tempDir/
+	//   ├── dir0/
+	//   │   ├── file.txt
+	//   │   └── sub/
+	//   │       └── file.txt
+	//   ├── ...
+	//   └── dir7/
+	//       ├── file.txt
+	//       └── sub/
+	//           └── file.txt
+	var paths []string
+	for i := 0; i < 8; i++ {
+		dir := "dir" + string(rune('0'+i))
+		paths = append(paths, dir+"/file.txt", dir+"/sub/file.txt")
+	}
+	tempDir := createTestTree(t, paths...)
+
+	t.Run("every match", func(t *testing.T) {
+		sequential, err := FindDownMultiple("file.txt", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+
+		options := &Options{Cwd: tempDir, Depth: -1, DownConcurrency: 4}
+		results, err := FindDownMultiple("file.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		sort.Strings(sequential)
+		sort.Strings(results)
+		if !reflect.DeepEqual(results, sequential) {
+			t.Errorf("Expected %v, got %v", sequential, results)
+		}
+	})
+
+	t.Run("Concurrency keeps FindDownMultiple sequential", func(t *testing.T) {
+		sequential, err := FindDownMultiple("file.txt", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+
+		results, err := FindDownMultiple("file.txt", &Options{Cwd: tempDir, Depth: -1, Concurrency: 4})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if !reflect.DeepEqual(results, sequential) {
+			t.Errorf("Expected %v, got %v", sequential, results)
+		}
+	})
+
+	t.Run("Limit is never exceeded", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			options := &Options{Cwd: tempDir, Depth: -1, DownConcurrency: 4, Limit: 3}
+			results, err := FindDownMultiple("file.txt", options)
+			if err != nil {
+				t.Fatalf("FindDownMultiple failed: %v", err)
+			}
+			if len(results) != 3 {
+				t.Fatalf("Expected 3 results, got %d: %v", len(results), results)
+			}
+		}
+	})
+
+	t.Run("MaxResults", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, DownConcurrency: 4, MaxResults: 5}
+		results, err := FindDownMultiple("file.txt", options)
+		if !errors.Is(err, ErrTooManyResults) {
+			t.Errorf("Expected ErrTooManyResults, got %v", err)
+		}
+		if len(results) != 5 {
+			t.Errorf("Expected 5 results, got %d", len(results))
+		}
+	})
+
+	t.Run("unreadable Cwd", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "missing"), Depth: -1, DownConcurrency: 4}
+		if _, err := FindDownMultiple("file.txt", options); err == nil {
+			t.Error("Expected an error for a missing Cwd")
+		}
+	})
+}
+
+// goroutineTracer records the most goroutines running while directories were listed
+type goroutineTracer struct {
+	peak atomic.Int64
+}
+
+func (g *goroutineTracer) OnReadDir(path string, dur time.Duration, err error) {
+	n := int64(runtime.NumGoroutine())
+	for {
+		peak := g.peak.Load()
+		if n <= peak || g.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+func (g *goroutineTracer) OnStat(path string, dur time.Duration, err error) {}
+
+func TestFindDownMultipleConcurrentWorkers(t *testing.T) {
+	// tempDir/
+	//   ├── dir000/
+	//   ├── ...
+	//   └── dir199/
+	var paths []string
+	for i := 0; i < 200; i++ {
+		paths = append(paths, fmt.Sprintf("dir%03d/", i))
+	}
+	tempDir := createTestTree(t, paths...)
+
+	before := runtime.NumGoroutine()
+	tracer := &goroutineTracer{}
+	options := &Options{Cwd: tempDir, Depth: -1, DownConcurrency: 4, Tracer: tracer}
+	if _, err := FindDownMultiple("file.txt", options); err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+
+	// The workers are the only goroutines the walk starts, however wide the tree is
+	if peak := tracer.peak.Load(); peak > int64(before+options.DownConcurrency) {
+		t.Errorf("Expected at most %d goroutines, got %d", before+options.DownConcurrency, peak)
+	}
+}
+
+func TestFindDownMultipleConcurrentError(t *testing.T) {
+	// tempDir/
+	//   ├── dir00/
+	//   │   └── a/
+	//   │       └── b/
+	//   │           └── .no-scan
+	//   ├── dir01/
+	//   │   └── .no-scan
+	//   ├── ...
+	//   └── dir15/
+	//       └── .no-scan
+	paths := []string{"dir00/a/b/.no-scan"}
+	for i := 1; i < 16; i++ {
+		paths = append(paths, fmt.Sprintf("dir%02d/.no-scan", i))
+	}
+	tempDir := createTestTree(t, paths...)
+
+	_, expected := FindDownMultiple("file.txt", &Options{Cwd: tempDir, Depth: -1, Strategy: DepthFirst, AbortOnFile: ".no-scan"})
+	if !errors.Is(expected, ErrAborted) {
+		t.Fatalf("Expected ErrAborted, got %v", expected)
+	}
+
+	// Whichever worker fails first, the error is the one of the first directory in walk order
+	for i := 0; i < 20; i++ {
+		options := &Options{Cwd: tempDir, Depth: -1, DownConcurrency: 4, AbortOnFile: ".no-scan"}
+		_, err := FindDownMultiple("file.txt", options)
+		if err == nil || err.Error() != expected.Error() {
+			t.Fatalf("Expected %v, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package findup
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Cursor is an opaque position in a downward walk, returned by FindDownPage when
+// the limit is reached. It can be serialized with MarshalText to resume the walk later.
+type Cursor struct {
+	name   string
+	frames []walkFrame
+}
+
+// cursorState is the serialized form of a Cursor
+type cursorState struct {
+	Name   string      `json:"name"`
+	Frames []walkFrame `json:"frames"`
+}
+
+// MarshalText encodes the cursor as an opaque string
+func (c *Cursor) MarshalText() ([]byte, error) {
+	data, err := json.Marshal(cursorState{Name: c.name, Frames: c.frames})
+	if err != nil {
+		return nil, err
+	}
+
+	text := make([]byte, base64.RawURLEncoding.EncodedLen(len(data)))
+	base64.RawURLEncoding.Encode(text, data)
+	return text, nil
+}
+
+// UnmarshalText decodes a cursor previously encoded with MarshalText
+func (c *Cursor) UnmarshalText(text []byte) error {
+	data := make([]byte, base64.RawURLEncoding.DecodedLen(len(text)))
+	n, err := base64.RawURLEncoding.Decode(data, text)
+	if err != nil {
+		return err
+	}
+
+	var state cursorState
+	if err := json.Unmarshal(data[:n], &state); err != nil {
+		return err
+	}
+
+	c.name = state.Name
+	c.frames = state.Frames
+	return nil
+}
+
+// FindDownPage finds up to Limit files or directories by walking down descendant directories.
+// When the limit is reached it also returns a Cursor that FindDownResume continues from.
+// A nil cursor means the walk is complete.
+func FindDownPage(name string, options *Options) ([]string, *Cursor, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer recordElapsed(opts)
+
+	return findDownPage([]walkFrame{{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}}, name, opts)
+}
+
+// FindDownResume continues a walk from cursor, returning the next page of up to Limit matches
+// and a cursor for the following page. The options should match those of the first page.
+func FindDownResume(cursor *Cursor, options *Options) ([]string, *Cursor, error) {
+	if cursor == nil {
+		return nil, nil, errors.New("findup: nil cursor")
+	}
+
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer recordElapsed(opts)
+
+	frames := append([]walkFrame(nil), cursor.frames...)
+	return findDownPage(frames, cursor.name, opts)
+}
+
+func findDownPage(frames []walkFrame, name string, options *Options) ([]string, *Cursor, error) {
+	var matches []EntryMatch
+	remaining, err := walkDownMultiple(frames, name, options, &matches)
+	if err != nil && !options.CollectErrors {
+		return nil, nil, err
+	}
+
+	var results []string
+	for _, match := range matches {
+		results = append(results, formatPath(match.Path, options))
+	}
+
+	if len(remaining) == 0 {
+		return results, nil, err
+	}
+	return results, &Cursor{name: name, frames: remaining}, err
+}
@@ -0,0 +1,130 @@
+package findup
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindDownPage(t *testing.T) {
+	// tempDir/
+	//   ├── a.txt
+	//   ├── b.txt
+	//   ├── dir1/
+	//   │   ├── c.txt
+	//   │   └── dir2/
+	//   │       └── d.txt
+	//   └── dir3/
+	//       └── e.txt
+	tempDir := createTestTree(t,
+		"a.txt",
+		"b.txt",
+		"dir1/c.txt",
+		"dir1/dir2/d.txt",
+		"dir3/e.txt",
+	)
+
+	all, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1})
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("Expected 5 results, got %v", all)
+	}
+
+	t.Run("FindDownPage and FindDownResume cover all matches", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, Limit: 3}
+		first, cursor, err := FindDownPage("*.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownPage failed: %v", err)
+		}
+		if len(first) != 3 {
+			t.Fatalf("Expected 3 results in the first page, got %v", first)
+		}
+		if cursor == nil {
+			t.Fatal("Expected a cursor after the first page")
+		}
+
+		second, cursor, err := FindDownResume(cursor, options)
+		if err != nil {
+			t.Fatalf("FindDownResume failed: %v", err)
+		}
+		if cursor != nil {
+			t.Errorf("Expected the walk to be complete, got cursor %+v", cursor)
+		}
+
+		paged := append(first, second...)
+		if !reflect.DeepEqual(paged, all) {
+			t.Errorf("Expected %v, got %v", all, paged)
+		}
+	})
+
+	t.Run("Cursor survives serialization", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, Limit: 1}
+		var paged []string
+
+		results, cursor, err := FindDownPage("*.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownPage failed: %v", err)
+		}
+		paged = append(paged, results...)
+
+		for cursor != nil {
+			text, err := cursor.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText failed: %v", err)
+			}
+			var restored Cursor
+			if err := restored.UnmarshalText(text); err != nil {
+				t.Fatalf("UnmarshalText failed: %v", err)
+			}
+
+			results, cursor, err = FindDownResume(&restored, options)
+			if err != nil {
+				t.Fatalf("FindDownResume failed: %v", err)
+			}
+			paged = append(paged, results...)
+		}
+
+		if !reflect.DeepEqual(paged, all) {
+			t.Errorf("Expected %v, got %v", all, paged)
+		}
+	})
+
+	t.Run("FindDownPage without reaching the limit", func(t *testing.T) {
+		results, cursor, err := FindDownPage("*.txt", &Options{Cwd: filepath.Join(tempDir, "dir3"), Limit: 3})
+		if err != nil {
+			t.Fatalf("FindDownPage failed: %v", err)
+		}
+		if len(results) != 1 || cursor != nil {
+			t.Errorf("Expected a single result and no cursor, got %v and %+v", results, cursor)
+		}
+	})
+
+	t.Run("FindDownPage with a byte budget", func(t *testing.T) {
+		// Each file holds 12 bytes, so a 30 byte budget fits two per page
+		options := &Options{Cwd: tempDir, Depth: -1, MaxTotalBytes: 30}
+		var paged []string
+
+		results, cursor, err := FindDownPage("*.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownPage failed: %v", err)
+		}
+		paged = append(paged, results...)
+
+		for cursor != nil {
+			results, cursor, err = FindDownResume(cursor, options)
+			if err != nil {
+				t.Fatalf("FindDownResume failed: %v", err)
+			}
+			if len(results) > 2 {
+				t.Errorf("Expected at most 2 results per page, got %v", results)
+			}
+			paged = append(paged, results...)
+		}
+
+		if !reflect.DeepEqual(paged, all) {
+			t.Errorf("Expected %v, got %v", all, paged)
+		}
+	})
+}
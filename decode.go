@@ -0,0 +1,60 @@
+package findup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownFormat is returned by FindUpAndDecode when no decoder handles the extension of the file found
+var ErrUnknownFormat = errors.New("findup: unknown file format")
+
+// FindUpAndDecode finds the nearest file matching name by walking up parent directories and decodes it
+// into out, picking the decoder from its extension: .json, .yaml or .yml, or .toml. It returns the path
+// of the file, or an empty string with out untouched when nothing is found.
+func FindUpAndDecode(name string, out any, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+	opts.Type = FileType
+
+	result, err := findUpInDir(opts.Cwd, name, opts, opts.StopAt)
+	if err != nil || result == "" {
+		return "", err
+	}
+
+	err = decodeFile(result, out)
+	return formatPath(result, opts), err
+}
+
+// decodeFile decodes the file at path into out with the decoder for its extension
+func decodeFile(path string, out any) error {
+	var unmarshal func(data []byte, out any) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		unmarshal = json.Unmarshal
+	case ".yaml", ".yml":
+		unmarshal = yaml.Unmarshal
+	case ".toml":
+		unmarshal = toml.Unmarshal
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(data, out); err != nil {
+		return fmt.Errorf("findup: decoding %s: %w", path, err)
+	}
+	return nil
+}
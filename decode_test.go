@@ -0,0 +1,85 @@
+package findup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type decodeConfig struct {
+	Name  string   `json:"name" yaml:"name" toml:"name"`
+	Port  int      `json:"port" yaml:"port" toml:"port"`
+	Tags  []string `json:"tags" yaml:"tags" toml:"tags"`
+	Debug bool     `json:"debug" yaml:"debug" toml:"debug"`
+}
+
+func TestFindUpAndDecode(t *testing.T) {
+	// tempDir/
+	//   ├── app.json
+	//   ├── app.yaml
+	//   ├── app.toml
+	//   ├── app.ini
+	//   ├── broken.json
+	//   └── dir1/
+	tempDir := createTestTree(t, "dir1/")
+	files := map[string]string{
+		"app.json":    `{"name": "api", "port": 8080, "tags": ["a", "b"], "debug": true}`,
+		"app.yaml":    "name: api\nport: 8080\ntags: [a, b]\ndebug: true\n",
+		"app.toml":    "name = \"api\"\nport = 8080\ntags = [\"a\", \"b\"]\ndebug = true\n",
+		"app.ini":     "name=api\n",
+		"broken.json": `{"name": `,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	cwd := filepath.Join(tempDir, "dir1")
+	expected := decodeConfig{Name: "api", Port: 8080, Tags: []string{"a", "b"}, Debug: true}
+
+	for _, name := range []string{"app.json", "app.yaml", "app.toml"} {
+		t.Run(name, func(t *testing.T) {
+			var config decodeConfig
+			result, err := FindUpAndDecode(name, &config, &Options{Cwd: cwd})
+			if err != nil {
+				t.Fatalf("FindUpAndDecode failed: %v", err)
+			}
+			if result != filepath.Join(tempDir, name) {
+				t.Errorf("Expected %s, got %s", filepath.Join(tempDir, name), result)
+			}
+			if config.Name != expected.Name || config.Port != expected.Port || len(config.Tags) != 2 || !config.Debug {
+				t.Errorf("Expected %+v, got %+v", expected, config)
+			}
+		})
+	}
+
+	t.Run("unknown extension", func(t *testing.T) {
+		var config decodeConfig
+		if _, err := FindUpAndDecode("app.ini", &config, &Options{Cwd: cwd}); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("Expected ErrUnknownFormat, got %v", err)
+		}
+	})
+
+	t.Run("invalid content", func(t *testing.T) {
+		var config decodeConfig
+		result, err := FindUpAndDecode("broken.json", &config, &Options{Cwd: cwd})
+		if err == nil {
+			t.Error("Expected a decoding error")
+		}
+		if result != filepath.Join(tempDir, "broken.json") {
+			t.Errorf("Expected the path of the broken file, got %s", result)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		config := decodeConfig{Name: "default"}
+		result, err := FindUpAndDecode("missing.json", &config, &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpAndDecode failed: %v", err)
+		}
+		if result != "" || config.Name != "default" {
+			t.Errorf("Expected no match and untouched config, got %s and %+v", result, config)
+		}
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package findup
+
+// deviceID reports that device IDs are unavailable on this platform,
+// so mount point boundaries are never detected
+func deviceID(options *Options, path string) (uint64, bool) {
+	return 0, false
+}
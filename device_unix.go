@@ -0,0 +1,20 @@
+//go:build unix
+
+package findup
+
+import "syscall"
+
+// deviceID returns the ID of the device containing path
+func deviceID(options *Options, path string) (uint64, bool) {
+	info, err := stat(options, path)
+	if err != nil {
+		return 0, false
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+
+	return uint64(stat.Dev), true
+}
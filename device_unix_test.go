@@ -0,0 +1,121 @@
+//go:build unix
+
+package findup
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDeviceID(t *testing.T) {
+	tempDir := createTestTree(t, "dir1/")
+
+	parent, ok := deviceID(&Options{}, tempDir)
+	if !ok {
+		t.Fatalf("Expected device ID for %s", tempDir)
+	}
+	child, ok := deviceID(&Options{}, filepath.Join(tempDir, "dir1"))
+	if !ok {
+		t.Fatalf("Expected device ID for %s", filepath.Join(tempDir, "dir1"))
+	}
+	if parent != child {
+		t.Errorf("Expected same device for parent and child, got %d and %d", parent, child)
+	}
+}
+
+func TestFindDownStayOnDevice(t *testing.T) {
+	// tempDir/
+	//   └── dir1/
+	//       └── dir2/
+	//           └── file.txt
+	tempDir := createTestTree(t, "dir1/dir2/file.txt")
+
+	t.Run("FindDown stays on the same device", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, StayOnDevice: true}
+		result, err := FindDown("file.txt", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "dir1", "dir2", "file.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDown skips mounted directories", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("Requires /proc mounted on Linux")
+		}
+		rootDevice, ok := deviceID(&Options{}, "/")
+		procDevice, procOK := deviceID(&Options{}, "/proc")
+		if !ok || !procOK || rootDevice == procDevice {
+			t.Skip("/proc is not a separate mount")
+		}
+
+		options := &Options{Cwd: "/", Depth: 1, Type: DirectoryType, AllowSymlinks: true}
+		result, err := FindDown("self", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "/proc/self" {
+			t.Errorf("Expected /proc/self when crossing mount points, got %s", result)
+		}
+
+		options.StayOnDevice = true
+		result, err = FindDown("self", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result when not crossing mount points, got %s", result)
+		}
+	})
+}
+
+func TestIsMountPoint(t *testing.T) {
+	// tempDir/
+	//   └── dir1/
+	tempDir := createTestTree(t, "dir1/")
+	matcher := IsMountPoint()
+
+	t.Run("filesystem root", func(t *testing.T) {
+		result, matched, err := matcher("/")
+		if err != nil {
+			t.Fatalf("IsMountPoint failed: %v", err)
+		}
+		if !matched || result != "/" {
+			t.Errorf("Expected / to be a mount point, got %q", result)
+		}
+	})
+
+	t.Run("directory on its parent's device", func(t *testing.T) {
+		_, matched, err := matcher(filepath.Join(tempDir, "dir1"))
+		if err != nil {
+			t.Fatalf("IsMountPoint failed: %v", err)
+		}
+		if matched {
+			t.Error("Expected a plain directory not to be a mount point")
+		}
+	})
+
+	t.Run("FindUpWithMatcher finds the nearest mount point", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("Requires /proc mounted on Linux")
+		}
+		rootDevice, rootOK := deviceID(&Options{}, "/")
+		procDevice, procOK := deviceID(&Options{}, "/proc")
+		sysDevice, sysOK := deviceID(&Options{}, "/proc/sys/kernel")
+		if !rootOK || !procOK || !sysOK || rootDevice == procDevice || procDevice != sysDevice {
+			t.Skip("/proc is not a separate mount containing /proc/sys/kernel")
+		}
+
+		result, err := FindUpWithMatcher(IsMountPoint(), &Options{Cwd: "/proc/sys/kernel"})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != "/proc" {
+			t.Errorf("Expected /proc, got %s", result)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package findup
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FindExecutable finds an executable file named name by walking up parent directories, like
+// exec.LookPath does over PATH. On Windows, names without an extension are tried with each
+// extension in PATHEXT, otherwise the file must have an executable permission bit set.
+func FindExecutable(name string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+	opts.Type = FileType
+
+	candidates := executableNames(name)
+
+	var result string
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		for _, candidate := range candidates {
+			target := filepath.Join(current, candidate)
+			if matches, err := pathMatches(target, opts); err != nil || !matches {
+				continue
+			}
+			if isExecutable(opts, target) {
+				foundMatch(opts, target, depth)
+				result = target
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+
+	return formatPath(result, opts), err
+}
+
+// executableNames returns the file names an executable called name may have on this platform
+func executableNames(name string) []string {
+	if runtime.GOOS != "windows" || filepath.Ext(name) != "" {
+		return []string{name}
+	}
+
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+
+	var names []string
+	for _, ext := range strings.Split(pathExt, ";") {
+		if ext != "" {
+			names = append(names, name+strings.ToLower(ext))
+		}
+	}
+	return names
+}
+
+// isExecutable reports whether path can be executed, which on Windows is decided by its extension alone
+func isExecutable(options *Options, path string) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	info, err := stat(options, path)
+	return err == nil && info.Mode().Perm()&0111 != 0
+}
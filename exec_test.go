@@ -0,0 +1,68 @@
+package findup
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFindExecutable(t *testing.T) {
+	t.Run("FindExecutable requires the executable bit", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permission bits are not used on Windows")
+		}
+
+		// tempDir/
+		//   ├── tool      (executable)
+		//   └── dir1/
+		//       └── tool  (not executable)
+		tempDir := createTestTree(t, "tool", "dir1/tool")
+		if err := os.Chmod(filepath.Join(tempDir, "tool"), 0755); err != nil {
+			t.Fatalf("Failed to make tool executable: %v", err)
+		}
+
+		result, err := FindExecutable("tool", &Options{Cwd: filepath.Join(tempDir, "dir1")})
+		if err != nil {
+			t.Fatalf("FindExecutable failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "tool")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindExecutable tries PATHEXT extensions", func(t *testing.T) {
+		if runtime.GOOS != "windows" {
+			t.Skip("PATHEXT only applies on Windows")
+		}
+
+		// tempDir/
+		//   ├── tool.exe
+		//   └── dir1/
+		//       └── tool.txt
+		tempDir := createTestTree(t, "tool.exe", "dir1/tool.txt")
+		t.Setenv("PATHEXT", ".COM;.EXE")
+
+		result, err := FindExecutable("tool", &Options{Cwd: filepath.Join(tempDir, "dir1")})
+		if err != nil {
+			t.Fatalf("FindExecutable failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "tool.exe")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindExecutable without match", func(t *testing.T) {
+		tempDir := createTestTree(t, "dir1/")
+
+		result, err := FindExecutable("missing-tool", &Options{Cwd: filepath.Join(tempDir, "dir1"), StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindExecutable failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
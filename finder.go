@@ -0,0 +1,215 @@
+package findup
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"testing/fstest"
+)
+
+// Finder finds files and directories in an fs.FS instead of the operating system's file system.
+// Paths are slash-separated and relative to the root of the file system as fs.FS expects, with "."
+// being the root, and a leading "/" in Cwd or StopAt is dropped. Finder honors the Cwd, StopAt, Type, Limit, Depth and Strategy options.
+type Finder struct {
+	fsys fs.FS
+}
+
+// NewFinder returns a Finder searching fsys
+func NewFinder(fsys fs.FS) *Finder {
+	return &Finder{fsys: fsys}
+}
+
+// NewMemFinder returns a Finder searching an in-memory tree built from a map of slash-separated paths
+// to file contents, where paths ending in "/" are empty directories. Parent directories are created
+// as needed, which makes it handy for table-driven tests of discovery logic without temporary directories.
+func NewMemFinder(files map[string]string) *Finder {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		if strings.HasSuffix(name, "/") {
+			fsys[strings.TrimSuffix(name, "/")] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+			continue
+		}
+		fsys[name] = &fstest.MapFile{Data: []byte(content), Mode: 0644}
+	}
+	return NewFinder(fsys)
+}
+
+// FindUp finds a file or directory by walking up parent directories
+func (f *Finder) FindUp(name string, options *Options) (string, error) {
+	opts := f.resolveOptions(options)
+	opts.Limit = 1
+
+	results, err := f.findUp(name, opts)
+	if err != nil || len(results) == 0 {
+		return "", err
+	}
+	return results[0], nil
+}
+
+// FindUpMultiple finds multiple files or directories by walking up parent directories
+func (f *Finder) FindUpMultiple(name string, options *Options) ([]string, error) {
+	return f.findUp(name, f.resolveOptions(options))
+}
+
+// FindDown finds a file or directory by walking down descendant directories
+func (f *Finder) FindDown(name string, options *Options) (string, error) {
+	opts := f.resolveOptions(options)
+	opts.Limit = 1
+
+	results, err := f.findDown(name, opts)
+	if err != nil || len(results) == 0 {
+		return "", err
+	}
+	return results[0], nil
+}
+
+// FindDownMultiple finds multiple files or directories by walking down descendant directories
+func (f *Finder) FindDownMultiple(name string, options *Options) ([]string, error) {
+	return f.findDown(name, f.resolveOptions(options))
+}
+
+// resolveOptions copies options, or the default options when nil, and cleans Cwd and StopAt
+func (f *Finder) resolveOptions(options *Options) *Options {
+	if options == nil {
+		options = packageDefaults()
+	}
+
+	opts := *options
+	opts.Cwd = fsPath(opts.Cwd)
+	if opts.StopAt != "" {
+		opts.StopAt = fsPath(opts.StopAt)
+	}
+	return &opts
+}
+
+// fsPath cleans name into a path relative to the root of the file system, so walking up
+// with path.Dir always ends at "."
+func fsPath(name string) string {
+	name = strings.TrimLeft(path.Clean(name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (f *Finder) findUp(name string, options *Options) ([]string, error) {
+	var results []string
+
+	for dir := options.Cwd; options.StopAt == "" || dir != options.StopAt; dir = path.Dir(dir) {
+		matches, err := f.findInDir(dir, name, options)
+		if err != nil {
+			return results, err
+		}
+		for _, match := range matches {
+			results = append(results, match)
+			if options.Limit > 0 && len(results) >= options.Limit {
+				return results, nil
+			}
+		}
+
+		if dir == "." || dir == path.Dir(dir) {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (f *Finder) findDown(name string, options *Options) ([]string, error) {
+	var results []string
+	frames := []walkFrame{{Dir: options.Cwd}}
+
+	for len(frames) > 0 {
+		var frame walkFrame
+		if options.Strategy == DepthFirst {
+			frame, frames = frames[len(frames)-1], frames[:len(frames)-1]
+		} else {
+			frame, frames = frames[0], frames[1:]
+		}
+
+		entries, err := fs.ReadDir(f.fsys, frame.Dir)
+		if err != nil {
+			if frame.Dir == options.Cwd {
+				return nil, err
+			}
+			continue
+		}
+
+		matches, err := f.findInDir(frame.Dir, name, options)
+		if err != nil {
+			return results, err
+		}
+		for _, match := range matches {
+			results = append(results, match)
+			if options.Limit > 0 && len(results) >= options.Limit {
+				return results, nil
+			}
+		}
+
+		// Queue the subdirectories within Depth, reversed for depth-first so they pop in name order
+		if options.Depth >= 0 && frame.Depth+1 > options.Depth {
+			continue
+		}
+		var subdirs []walkFrame
+		for _, entry := range entries {
+			if entry.IsDir() {
+				subdirs = append(subdirs, walkFrame{Dir: path.Join(frame.Dir, entry.Name()), Depth: frame.Depth + 1})
+			}
+		}
+		if options.Strategy == DepthFirst {
+			for i := len(subdirs) - 1; i >= 0; i-- {
+				frames = append(frames, subdirs[i])
+			}
+		} else {
+			frames = append(frames, subdirs...)
+		}
+	}
+
+	return results, nil
+}
+
+// findInDir returns the entries of dir matching name, which may be a glob pattern
+func (f *Finder) findInDir(dir, name string, options *Options) ([]string, error) {
+	if !IsGlob(name) {
+		target := path.Join(dir, name)
+		info, err := fs.Stat(f.fsys, target)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil || !typeMatches(info.Mode(), options.Type) {
+			return nil, err
+		}
+		return []string{target}, nil
+	}
+
+	if _, err := path.Match(name, ""); err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(f.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []string
+	for _, entry := range entries {
+		if matched, _ := path.Match(name, entry.Name()); matched && typeMatches(entry.Type(), options.Type) {
+			results = append(results, path.Join(dir, entry.Name()))
+		}
+	}
+	return results, nil
+}
+
+// typeMatches reports whether an entry with mode is of type pathType
+func typeMatches(mode fs.FileMode, pathType PathType) bool {
+	switch pathType {
+	case FileType:
+		return mode.IsRegular()
+	case DirectoryType:
+		return mode.IsDir()
+	case SymlinkType:
+		return mode&fs.ModeSymlink != 0
+	}
+	return true
+}
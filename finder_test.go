@@ -0,0 +1,138 @@
+package findup
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFinder(t *testing.T) {
+	// ./
+	//   ├── go.mod
+	//   ├── README.md
+	//   ├── cmd/
+	//   │   └── app/
+	//   │       ├── go.mod
+	//   │       └── main.go
+	//   ├── docs/
+	//   └── internal/
+	//       └── util/
+	//           └── util.go
+	finder := NewMemFinder(map[string]string{
+		"go.mod":                "module example.com/root",
+		"README.md":             "# root",
+		"cmd/app/go.mod":        "module example.com/app",
+		"cmd/app/main.go":       "package main",
+		"docs/":                 "",
+		"internal/util/util.go": "package util",
+	})
+
+	upTests := []struct {
+		name     string
+		target   string
+		options  *Options
+		expected []string
+	}{
+		{"nearest go.mod", "go.mod", &Options{Cwd: "cmd/app"}, []string{"cmd/app/go.mod", "go.mod"}},
+		{"from the root", "go.mod", &Options{Cwd: "."}, []string{"go.mod"}},
+		{"glob pattern", "*.md", &Options{Cwd: "internal/util"}, []string{"README.md"}},
+		{"StopAt excludes the root", "go.mod", &Options{Cwd: "internal/util", StopAt: "."}, nil},
+		{"directory type", "cmd", &Options{Cwd: "cmd/app", Type: DirectoryType}, []string{"cmd"}},
+		{"file type skips directories", "docs", &Options{Cwd: "."}, nil},
+	}
+
+	for _, tt := range upTests {
+		t.Run("FindUpMultiple "+tt.name, func(t *testing.T) {
+			results, err := finder.FindUpMultiple(tt.target, tt.options)
+			if err != nil {
+				t.Fatalf("FindUpMultiple failed: %v", err)
+			}
+			if !reflect.DeepEqual(results, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, results)
+			}
+		})
+	}
+
+	t.Run("FindUp", func(t *testing.T) {
+		result, err := finder.FindUp("go.mod", &Options{Cwd: "cmd/app"})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "cmd/app/go.mod" {
+			t.Errorf("Expected cmd/app/go.mod, got %s", result)
+		}
+	})
+
+	downTests := []struct {
+		name     string
+		target   string
+		options  *Options
+		expected []string
+	}{
+		{"all go files", "*.go", &Options{Cwd: ".", Depth: -1}, []string{"cmd/app/main.go", "internal/util/util.go"}},
+		{"depth limit", "*.go", &Options{Cwd: ".", Depth: 1}, nil},
+		{"from a subdirectory", "go.mod", &Options{Cwd: "cmd", Depth: -1}, []string{"cmd/app/go.mod"}},
+		{"depth first", "*", &Options{Cwd: ".", Depth: -1, Type: DirectoryType, Strategy: DepthFirst},
+			[]string{"cmd", "docs", "internal", "cmd/app", "internal/util"}},
+	}
+
+	for _, tt := range downTests {
+		t.Run("FindDownMultiple "+tt.name, func(t *testing.T) {
+			results, err := finder.FindDownMultiple(tt.target, tt.options)
+			if err != nil {
+				t.Fatalf("FindDownMultiple failed: %v", err)
+			}
+			if !reflect.DeepEqual(results, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, results)
+			}
+		})
+	}
+
+	t.Run("FindUp with an absolute Cwd", func(t *testing.T) {
+		result, err := finder.FindUp("README.md", &Options{Cwd: "/internal/util"})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "README.md" {
+			t.Errorf("Expected README.md, got %s", result)
+		}
+
+		results, err := finder.FindUpMultiple("go.mod", &Options{Cwd: "/cmd/app", StopAt: "/"})
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		if expected := []string{"cmd/app/go.mod"}; !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDown", func(t *testing.T) {
+		result, err := finder.FindDown("go.mod", &Options{Cwd: ".", Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "go.mod" {
+			t.Errorf("Expected go.mod, got %s", result)
+		}
+	})
+
+	t.Run("FindDown invalid pattern", func(t *testing.T) {
+		if _, err := finder.FindDown("[", &Options{Cwd: "."}); err == nil {
+			t.Error("Expected an error for an invalid pattern")
+		}
+	})
+}
+
+func TestFinderMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/b/c/marker": &fstest.MapFile{},
+	}
+
+	result, err := NewFinder(fsys).FindUp("marker", &Options{Cwd: "a/b/c"})
+	if err != nil {
+		t.Fatalf("FindUp failed: %v", err)
+	}
+	if result != "a/b/c/marker" {
+		t.Errorf("Expected a/b/c/marker, got %s", result)
+	}
+}
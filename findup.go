@@ -3,12 +3,34 @@
 package findup
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
+// ErrTooManyResults is returned with the partial results when a findDownMultiple search exceeds MaxResults
+var ErrTooManyResults = errors.New("findup: too many results")
+
+// ErrAborted is returned with the partial results when a downward search finds AbortOnFile
+var ErrAborted = errors.New("findup: search aborted")
+
 // PathType represents the type of path to search for
 type PathType int
 
@@ -19,26 +41,221 @@ const (
 	DirectoryType
 	// BothType searches for both files and directories
 	BothType
+	// SymlinkType searches for symbolic links themselves, whatever they point to and regardless of AllowSymlinks
+	SymlinkType
 )
 
 // Options contains configuration options for find operations
 type Options struct {
 	// Cwd is the directory to start from (default: current working directory)
 	Cwd string
+	// AbsoluteCwd trusts Cwd to be an absolute, clean path and skips converting it (behavior is undefined otherwise)
+	AbsoluteCwd bool
 	// Type specifies the type of path to match
 	Type PathType
 	// AllowSymlinks determines if symbolic links should be matched
 	AllowSymlinks bool
+	// BothTypePreference decides whether single-result functions pick files or directories first
+	// when a pattern matches both in one directory (only for BothType)
+	BothTypePreference TypePreference
+	// PreferRealFiles makes single-result functions pick real entries over symbolic links in the same directory
+	PreferRealFiles bool
 	// StopAt is the directory where the search halts (only for findUp functions)
 	StopAt string
+	// StopAtAny lists more directories where upward searches halt, whichever is reached first
+	StopAtAny []string
+	// StopAtMarkers halts upward searches after the first directory containing any of these names,
+	// e.g. ".git" or "go.work", which is still searched unlike StopAt
+	StopAtMarkers []string
+	// Base confines the search to this directory: Cwd must be inside it, upward searches stop
+	// after it and returned paths are relative to it
+	Base string
+	// NameFunc computes the name or pattern to find in each directory visited instead of the one passed
+	// to the find functions, e.g. a file named after the directory
+	NameFunc func(dir string) string
+	// NameMatch decides which entry names match instead of the name or pattern passed to the find functions,
+	// which then list every directory they visit
+	NameMatch func(entryName string) bool
+	// RootAnchoredGlob matches names as slash-separated glob patterns against the path of each entry
+	// relative to StopAt, e.g. "packages/*/package.json" for a monorepo root (requires StopAt)
+	RootAnchoredGlob bool
+	// NormalizeUnicode compares entry names and the name or pattern in Unicode normalization form C, so
+	// "café.txt" matches an entry stored decomposed as on macOS (exact names then list every directory visited)
+	NormalizeUnicode bool
+	// GlobCaseInsensitive matches glob patterns against entry names ignoring case, e.g. "*.JPG" matches
+	// "photo.jpg", while exact names are still matched case-sensitively
+	GlobCaseInsensitive bool
+	// SkipDotfiles keeps glob patterns from matching names beginning with "." unless the pattern begins
+	// with "." too, as in shells, so "*" skips ".hidden"
+	SkipDotfiles bool
+	// ConfineSymlinks resolves each directory visited by findUp functions and halts the walk at the first one
+	// a symbolic link takes outside StopAt (only when StopAt is set)
+	ConfineSymlinks bool
+	// ResolveCwd determines if symbolic links in Cwd and StopAt are resolved before searching
+	ResolveCwd bool
+	// FallbackToResolvedCwd makes FindUp search again from Cwd with its symbolic links resolved when
+	// walking up its logical path finds nothing, e.g. for a package linked into node_modules
+	FallbackToResolvedCwd bool
+	// RelativeToMatch makes FindUpThenDown return the downward matches relative to the directory
+	// containing the upward match, e.g. the discovered project root
+	RelativeToMatch bool
+	// KeepRelative returns paths relative to Cwd when Cwd is a relative path, e.g. "../go.mod"
+	// for Cwd "." (ignored when Base is set, as paths are then relative to Base)
+	KeepRelative bool
+	// ForwardSlashes converts returned paths to use forward slashes as separators on every platform
+	ForwardSlashes bool
 	// Limit is the maximum number of matches to return (only for findUpMultiple functions)
 	Limit int
-	// Depth is the maximum number of directory levels to traverse (only for findDown functions)
+	// MaxResults bounds the matches a findDownMultiple search may accumulate: unlike Limit, which quietly ends
+	// the walk with that many matches, exceeding it returns ErrTooManyResults with the first MaxResults matches
+	// so large result sets have to go through FindDownSeq or FindDownPage (0 or less means no bound)
+	MaxResults int
+	// MaxTotalBytes ends a findDownMultiple search once the summed sizes of the matches exceed this many bytes,
+	// e.g. to collect files up to a budget, and the match that exceeds it is left out (0 or less means no budget)
+	MaxTotalBytes int64
+	// AbortOnFile is the name of a file, e.g. ".no-scan", that ends a downward search with ErrAborted as soon as
+	// a directory containing it is reached, before any of its matches, so directory owners can opt out of scans
+	AbortOnFile string
+	// IncludeOverBudget keeps the match that exceeds MaxTotalBytes as the last one returned
+	IncludeOverBudget bool
+	// StopAtFirstLevel makes findUpMultiple functions return only the matches of the nearest directory with any
+	StopAtFirstLevel bool
+	// PerDirLimit is the maximum number of matches to return from any single directory (only for findDownMultiple functions)
+	PerDirLimit int
+	// Depth is the maximum number of directory levels below Cwd to descend into, 0 matches only the entries
+	// directly in Cwd and a negative value means no limit (only for findDown functions)
 	Depth int
+	// MinDepth is the minimum depth below Cwd of the directories whose entries match, shallower directories
+	// are still walked through, so Depth and MinDepth bound matches to a range of levels (only for findDown functions)
+	MinDepth int
+	// StartDepth is the depth of Cwd in a larger tree, added to the depth of every directory
+	// so Depth limits a resumed subtree scan as if it started at the top (only for findDown functions)
+	StartDepth int
 	// Strategy determines the search strategy for findDown functions
 	Strategy SearchStrategy
+	// TraversalOrder determines the order in which subdirectories are visited (only for findDown functions)
+	TraversalOrder TraversalOrder
+	// IncludeSelf determines if the Cwd directory itself is a candidate (only for findDown functions)
+	IncludeSelf bool
+	// StayOnDevice keeps findDown functions from descending into directories on other devices than Cwd
+	StayOnDevice bool
+	// CollectErrors makes findDownMultiple functions skip directories that cannot be read and return
+	// their errors joined together along with the matches instead of aborting at the first one
+	CollectErrors bool
+	// Exclude skips descending into subdirectories whose base name matches any of these glob patterns
+	// (only for findDown functions)
+	Exclude []string
+	// OnPrune is called with each directory findDown functions do not descend into and the reason,
+	// "depth", "excluded" or "mount point"
+	OnPrune func(dir string, reason string)
+	// ExtGlob matches only entries whose extension, including the dot, matches this glob pattern,
+	// e.g. ".[jt]s" with the pattern "*" finds .js and .ts files but not .json files
+	ExtGlob string
+	// ExcludeNames rejects matches whose base name matches any of these glob patterns
+	ExcludeNames []string
+	// PatternsFromFile names a file of glob patterns, one per line with blank lines and lines starting with "#"
+	// ignored, and matches only entries whose base name matches one of them (relative paths are relative to Cwd)
+	PatternsFromFile string
+	// ModifiedAfter matches only entries modified after this time
+	ModifiedAfter time.Time
+	// ModifiedBefore matches only entries modified before this time
+	ModifiedBefore time.Time
+	// ModifiedWithin matches only entries modified within this duration before the call (ignored if ModifiedAfter is set)
+	ModifiedWithin time.Duration
+	// NewerThanFile matches only entries modified after this reference file, e.g. a build stamp,
+	// which is read once per call (relative paths are relative to Cwd)
+	NewerThanFile string
+	// TargetGlob matches only symbolic links whose target, as written in the link, matches this glob pattern,
+	// e.g. "../node_modules/*", whether or not the target exists (links are then not followed)
+	TargetGlob string
+	// ContentPrefix matches only files starting with these bytes (only for FileType)
+	ContentPrefix []byte
+	// Uid matches only entries owned by this user ID (ignored on platforms without Unix ownership)
+	Uid *int
+	// Gid matches only entries owned by this group ID (ignored on platforms without Unix ownership)
+	Gid *int
+	// XattrName matches only entries carrying this extended attribute (Linux and macOS only,
+	// nothing matches on other platforms)
+	XattrName string
+	// XattrValue additionally requires the XattrName attribute to hold exactly this value (nil accepts any value)
+	XattrValue []byte
+	// ContentRegexp matches only files whose content matches this regular expression (only for FileType)
+	ContentRegexp *regexp.Regexp
+	// MaxContentScanBytes bounds how much of each file ContentRegexp scans (0 or less scans whole files)
+	MaxContentScanBytes int64
+	// GitTrackedOnly matches only entries tracked by the git repository containing Cwd
+	GitTrackedOnly bool
+	// AllowOutsideGitRepo makes GitTrackedOnly a no-op outside a git repository instead of an error
+	AllowOutsideGitRepo bool
+	// RespectGitignore skips entries ignored by the .gitignore files of the git repository containing Cwd,
+	// including negated patterns, and does not descend into ignored directories
+	RespectGitignore bool
+	// RootMarkers overrides the markers DetectRoot looks for, in priority order
+	RootMarkers []RootMarker
+	// IncludeSiblings makes FindUpResult list the other entries in the directory of the match
+	IncludeSiblings bool
+	// GitStatus annotates matches returned as Match with their git status, running git once per match
+	GitStatus bool
+	// Seen skips matches already in the set and records new ones, so repeated findDownMultiple scans return only new matches
+	Seen map[string]struct{}
+	// Stats collects the number of directories visited, matches, pruned directories and elapsed time (nil disables it)
+	Stats *Stats
+	// Logger receives debug records for directories entered, matches and pruned directories (nil disables logging)
+	Logger *slog.Logger
+	// MatcherCache reuses matcher results across FindUpWithMatcher calls (only for deterministic matchers)
+	MatcherCache *MatcherCache
+	// Concurrency is the number of matchers FindUpWithMatcherAny runs at once (0 or less uses GOMAXPROCS)
+	Concurrency int
+	// DownConcurrency is the number of directories FindDownMultiple searches at once when above 1, returning
+	// matches in no particular order, and with Limit which matches are returned is unspecified
+	DownConcurrency int
+	// PerLevelTimeout bounds the time spent listing or matching a single directory while walking up,
+	// the directory is skipped with ErrLevelTimeout when it runs out (0 means no timeout)
+	PerLevelTimeout time.Duration
+	// MaxEntriesPerDir caps the entries read from each directory, which is then read in chunks in directory
+	// order and single-result functions stop reading it at the first match, unless PreferRealFiles or
+	// BothTypePreference need every match to choose from. With ReadDirFunc the first entries it lists are
+	// kept instead (0 or less reads every entry)
+	MaxEntriesPerDir int
+	// RetryPolicy retries directory listings and stats failing with transient errors (nil disables retries)
+	RetryPolicy *RetryPolicy
+	// ReadDirFunc lists the entries of a directory in name order (nil uses os.ReadDir)
+	ReadDirFunc func(dir string) ([]fs.DirEntry, error)
+	// Tracer receives the duration and outcome of each directory listing and stat (nil disables tracing)
+	Tracer Tracer
+	// OnError is called when an upward search skips a directory because of an error, such as a timeout or
+	// a directory that cannot be listed, returning a non-nil error aborts the search with it (nil skips the
+	// directory and continues, as when OnError is not set)
+	OnError func(dir string, err error) error
+
+	// started is when the options were resolved for the current call
+	started time.Time
+	// resolvedBase is Base with symbolic links resolved
+	resolvedBase string
+	// relativeTo is the absolute form of a relative Cwd when KeepRelative is set
+	relativeTo string
+	// newerThan is the modification time of NewerThanFile
+	newerThan time.Time
+	// includePatterns holds the patterns read from PatternsFromFile
+	includePatterns []string
+	// gitTracked holds the paths tracked by git when GitTrackedOnly is set
+	gitTracked map[string]struct{}
+	// gitignore evaluates the .gitignore files when RespectGitignore is set
+	gitignore *gitignore
 }
 
+// Direction represents the direction a match was found in relative to Cwd
+type Direction int
+
+const (
+	// NoDirection means the match was not found by FindBoth
+	NoDirection Direction = iota
+	// Up means the match was found in Cwd or one of its parent directories
+	Up
+	// Down means the match was found in a descendant directory of Cwd
+	Down
+)
+
 // SearchStrategy represents the search strategy for findDown functions
 type SearchStrategy int
 
@@ -49,9 +266,110 @@ const (
 	DepthFirst
 )
 
+// EntryMatch is a match found by walking down descendant directories along with its directory entry
+type EntryMatch struct {
+	// Path is the path of the match
+	Path string
+	// Entry is the directory entry of the match, whose Info is loaded lazily
+	Entry fs.DirEntry
+	// Depth is the depth below Cwd, plus StartDepth, of the directory the match was found in
+	Depth int
+}
+
+// Match is a match found by walking up parent directories along with the name that produced it
+type Match struct {
+	// Path is the path of the match
+	Path string
+	// MatchedName is the name or pattern that matched
+	MatchedName string
+	// DepthFromStopAt is the number of directories between StopAt and the directory containing
+	// the match, e.g. 2 for StopAt/a/b/go.mod (0 when StopAt is not set)
+	DepthFromStopAt int
+	// GitStatus is the git status of the match when the GitStatus option is set
+	GitStatus GitFileStatus
+	// Direction is the direction the match was found in by FindBoth
+	Direction Direction
+	// Depth is the signed number of levels between Cwd and the directory containing the match
+	// as found by FindBoth, negative above Cwd and positive below it
+	Depth int
+}
+
+// Result is a Match along with context about the directory it was found in
+type Result struct {
+	Match
+	// Siblings are the names of the other entries in the directory of the match, in name order,
+	// when IncludeSiblings is set
+	Siblings []string
+}
+
+// NameMatches holds the matches found for one of the names given to FindUpAnyMultiple
+type NameMatches struct {
+	// Name is the name or pattern that matched
+	Name string
+	// Paths are the matches for Name, nearest first
+	Paths []string
+}
+
+// PathWithoutExt returns Path without its extension, e.g. "src/config" for "src/config.json".
+// Dotfiles without another extension such as ".env" are returned unchanged.
+func (m Match) PathWithoutExt() string {
+	ext := filepath.Ext(m.Path)
+	if ext == filepath.Base(m.Path) {
+		return m.Path
+	}
+	return strings.TrimSuffix(m.Path, ext)
+}
+
+// TypePreference represents which type single-result functions pick when BothType matches several entries in one directory
+type TypePreference int
+
+const (
+	// NoPreference picks the first match in name order
+	NoPreference TypePreference = iota
+	// FilesFirst picks files over directories
+	FilesFirst
+	// DirsFirst picks directories over files
+	DirsFirst
+)
+
 // MatcherFunc is a function that determines if a directory matches the search criteria
 type MatcherFunc func(directory string) (string, bool, error)
 
+// TraversalOrder represents the order in which findDown functions visit subdirectories
+type TraversalOrder int
+
+const (
+	// NameAsc visits subdirectories in ascending name order
+	NameAsc TraversalOrder = iota
+	// NameDesc visits subdirectories in descending name order
+	NameDesc
+	// MTimeDesc visits the most recently modified subdirectories first
+	MTimeDesc
+	// MTimeAsc visits the least recently modified subdirectories first
+	MTimeAsc
+)
+
+// MatcherCache stores the result of a matcher function per directory so that
+// repeated FindUpWithMatcher calls sharing ancestors evaluate each directory once.
+// A cache must only be shared between calls using the same deterministic matcher.
+type MatcherCache struct {
+	mu      sync.Mutex
+	results map[string]matcherResult
+}
+
+type matcherResult struct {
+	path       string
+	shouldStop bool
+}
+
+// NewMatcherCache creates an empty matcher cache
+func NewMatcherCache() *MatcherCache {
+	return &MatcherCache{results: make(map[string]matcherResult)}
+}
+
+// DepthMatcherFunc is a function that determines if a directory at the given depth below Cwd matches the search criteria
+type DepthMatcherFunc func(directory string, depth int) (string, bool, error)
+
 // DefaultOptions returns default options
 func DefaultOptions() *Options {
 	return &Options{
@@ -64,327 +382,1476 @@ func DefaultOptions() *Options {
 	}
 }
 
-// FindUp finds a file or directory by walking up parent directories
-func FindUp(name string, options *Options) (string, error) {
+var (
+	defaultsMu sync.RWMutex
+	defaults   *Options
+)
+
+// SetDefaultOptions sets the options used by the find functions when they are passed nil options.
+// The options are copied, passing nil restores DefaultOptions. It is safe for concurrent use.
+func SetDefaultOptions(options *Options) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+
 	if options == nil {
-		options = DefaultOptions()
+		defaults = nil
+		return
 	}
-
 	opts := *options
-	if opts.Cwd == "" {
-		opts.Cwd = "."
-	}
+	defaults = &opts
+}
 
-	// Convert to absolute path
-	absCwd, err := filepath.Abs(opts.Cwd)
-	if err != nil {
-		return "", err
+// packageDefaults returns a copy of the options set with SetDefaultOptions, or DefaultOptions
+func packageDefaults() *Options {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+
+	if defaults == nil {
+		return DefaultOptions()
 	}
+	opts := *defaults
+	return &opts
+}
 
-	stopAt := opts.StopAt
-	if stopAt != "" {
-		stopAt, err = filepath.Abs(stopAt)
-		if err != nil {
-			return "", err
-		}
+// withCwd returns a copy of options, or of the default options when nil, starting from cwd
+func withCwd(options *Options, cwd string) *Options {
+	if options == nil {
+		options = packageDefaults()
 	}
 
-	return findUpInDir(absCwd, name, &opts, stopAt)
+	opts := *options
+	opts.Cwd = cwd
+	return &opts
 }
 
-// FindUpMultiple finds multiple files or directories by walking up parent directories
-func FindUpMultiple(name string, options *Options) ([]string, error) {
+// resolveOptions copies options, applying defaults and resolving paths and relative settings
+func resolveOptions(options *Options) (*Options, error) {
 	if options == nil {
-		options = DefaultOptions()
+		options = packageDefaults()
 	}
 
 	opts := *options
+	opts.started = time.Now()
 	if opts.Cwd == "" {
 		opts.Cwd = "."
 	}
 
-	absCwd, err := filepath.Abs(opts.Cwd)
-	if err != nil {
-		return nil, err
+	// Convert to absolute path
+	var err error
+	relative := !opts.AbsoluteCwd && !filepath.IsAbs(opts.Cwd)
+	if !opts.AbsoluteCwd {
+		opts.Cwd, err = filepath.Abs(opts.Cwd)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	stopAt := opts.StopAt
-	if stopAt != "" {
-		stopAt, err = filepath.Abs(stopAt)
+	if opts.StopAt != "" {
+		opts.StopAt, err = filepath.Abs(opts.StopAt)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	var results []string
-	err = findUpMultipleInDir(absCwd, name, &opts, stopAt, &results)
-	return results, err
-}
-
-// FindUpWithMatcher finds a file or directory using a custom matcher function
-func FindUpWithMatcher(matcher MatcherFunc, options *Options) (string, error) {
-	if options == nil {
-		options = DefaultOptions()
+	if len(opts.StopAtAny) > 0 {
+		stopAtAny := make([]string, len(opts.StopAtAny))
+		for i, dir := range opts.StopAtAny {
+			stopAtAny[i], err = filepath.Abs(dir)
+			if err != nil {
+				return nil, err
+			}
+			if opts.ResolveCwd {
+				// Candidates that do not exist cannot be reached and are kept as they are
+				if resolved, err := filepath.EvalSymlinks(stopAtAny[i]); err == nil {
+					stopAtAny[i] = resolved
+				}
+			}
+		}
+		opts.StopAtAny = stopAtAny
 	}
 
-	opts := *options
-	if opts.Cwd == "" {
-		opts.Cwd = "."
+	// Resolve symbolic links so StopAt comparisons use the same form as Cwd
+	if opts.ResolveCwd {
+		opts.Cwd, err = filepath.EvalSymlinks(opts.Cwd)
+		if err != nil {
+			return nil, err
+		}
+		if opts.StopAt != "" {
+			opts.StopAt, err = filepath.EvalSymlinks(opts.StopAt)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	absCwd, err := filepath.Abs(opts.Cwd)
-	if err != nil {
-		return "", err
+	// Remember the directory a relative Cwd stands for to make results relative to it
+	opts.relativeTo = ""
+	if relative && opts.KeepRelative {
+		opts.relativeTo = opts.Cwd
 	}
 
-	stopAt := opts.StopAt
-	if stopAt != "" {
-		stopAt, err = filepath.Abs(stopAt)
-		if err != nil {
-			return "", err
+	if opts.ExtGlob != "" {
+		if _, err := matchesGlob("", opts.ExtGlob); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TargetGlob != "" {
+		if _, err := matchesGlob("", opts.TargetGlob); err != nil {
+			return nil, err
 		}
 	}
 
-	return findUpWithMatcherInDir(absCwd, matcher, &opts, stopAt)
-}
+	if opts.RootAnchoredGlob && opts.StopAt == "" {
+		return nil, fmt.Errorf("findup: RootAnchoredGlob requires StopAt")
+	}
 
-// FindDown finds a file or directory by walking down descendant directories
-func FindDown(name string, options *Options) (string, error) {
-	if options == nil {
-		options = DefaultOptions()
+	if opts.Base != "" {
+		if err := confineToBase(&opts); err != nil {
+			return nil, err
+		}
 	}
 
-	opts := *options
-	if opts.Cwd == "" {
-		opts.Cwd = "."
+	// Resolve the relative modification window against the current time
+	if opts.ModifiedWithin > 0 && opts.ModifiedAfter.IsZero() {
+		opts.ModifiedAfter = time.Now().Add(-opts.ModifiedWithin)
 	}
 
-	absCwd, err := filepath.Abs(opts.Cwd)
-	if err != nil {
-		return "", err
+	opts.includePatterns = nil
+	if opts.PatternsFromFile != "" {
+		opts.includePatterns, err = readPatterns(opts.PatternsFromFile, opts.Cwd)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return findDownInDir(absCwd, name, &opts, 0)
-}
+	opts.newerThan = time.Time{}
+	if opts.NewerThanFile != "" {
+		reference := opts.NewerThanFile
+		if !filepath.IsAbs(reference) {
+			reference = filepath.Join(opts.Cwd, reference)
+		}
+		info, err := os.Stat(reference)
+		if err != nil {
+			return nil, err
+		}
+		opts.newerThan = info.ModTime()
+	}
 
-// FindDownMultiple finds multiple files or directories by walking down descendant directories
-func FindDownMultiple(name string, options *Options) ([]string, error) {
-	if options == nil {
-		options = DefaultOptions()
+	opts.gitTracked = nil
+	if opts.GitTrackedOnly {
+		tracked, err := gitTrackedPaths(opts.Cwd)
+		if err != nil && !(err == ErrNotGitRepo && opts.AllowOutsideGitRepo) {
+			return nil, err
+		}
+		opts.gitTracked = tracked
 	}
 
-	opts := *options
-	if opts.Cwd == "" {
-		opts.Cwd = "."
+	opts.gitignore = nil
+	if opts.RespectGitignore {
+		opts.gitignore = newGitignore(opts.Cwd)
 	}
 
-	absCwd, err := filepath.Abs(opts.Cwd)
+	return &opts, nil
+}
+
+// FindUp finds a file or directory by walking up parent directories
+func FindUp(name string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	defer recordElapsed(opts)
 
-	var results []string
-	err = findDownMultipleInDir(absCwd, name, &opts, 0, &results)
-	return results, err
+	result, err := findUpInDir(opts.Cwd, name, opts, opts.StopAt)
+	if err == nil && result == "" && opts.FallbackToResolvedCwd {
+		result, err = findUpFromResolvedCwd(name, opts)
+	}
+	return formatPath(result, opts), err
 }
 
-// Helper functions
+// findUpFromResolvedCwd finds name walking up from Cwd with its symbolic links resolved,
+// or returns an empty string when that is the path already searched
+func findUpFromResolvedCwd(name string, options *Options) (string, error) {
+	resolved, err := filepath.EvalSymlinks(options.Cwd)
+	if err != nil || resolved == options.Cwd {
+		return "", nil
+	}
 
-// isGlobPattern checks if the name contains glob patterns
-func isGlobPattern(name string) bool {
-	return strings.Contains(name, "*") || strings.Contains(name, "?") || strings.Contains(name, "[")
-}
+	// Resolve StopAt as well so the physical walk can reach it
+	stopAt := options.StopAt
+	if stopAt != "" {
+		if resolvedStopAt, err := filepath.EvalSymlinks(stopAt); err == nil {
+			stopAt = resolvedStopAt
+		}
+	}
 
-// matchesGlob checks if a file matches a glob pattern
-func matchesGlob(filename, pattern string) (bool, error) {
-	matched, err := filepath.Match(pattern, filename)
-	return matched, err
+	return findUpInDir(resolved, name, options, stopAt)
 }
 
-func findUpInDir(dir, name string, options *Options, stopAt string) (string, error) {
-	current := dir
+// PrepareUp walks up from Cwd once and returns a function finding a name in the directories visited,
+// nearest first, for looking up many names from the same Cwd. The directories are not walked again,
+// but each lookup checks their entries afresh.
+func PrepareUp(options *Options) (func(name string) (string, error), error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
 
-	for {
-		// Check if we should stop at this directory
-		if stopAt != "" && current == stopAt {
-			break
-		}
+	var dirs []string
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		dirs = append(dirs, current)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Check if the target exists in current directory
-		if isGlobPattern(name) {
-			// Handle glob patterns by listing directory contents
-			entries, err := os.ReadDir(current)
-			if err == nil {
-				for _, entry := range entries {
-					entryName := entry.Name()
-					if matched, err := matchesGlob(entryName, name); err == nil && matched {
-						target := filepath.Join(current, entryName)
-						if matches, err := pathMatches(target, options); err == nil && matches {
-							return target, nil
-						}
-					}
+	return func(name string) (string, error) {
+		for depth, current := range dirs {
+			target, err := withLevelTimeout(opts, func() (string, error) {
+				return firstInDir(current, name, opts)
+			})
+			if err != nil {
+				if stop, err := skipDir(opts, current, err); stop {
+					return "", err
 				}
+				continue
 			}
-		} else {
-			// Handle exact filename match
-			target := filepath.Join(current, name)
-			if matches, err := pathMatches(target, options); err == nil && matches {
-				return target, nil
-			}
-		}
 
-		// Move to parent directory
-		parent := filepath.Dir(current)
-		if parent == current {
-			// Reached root directory
-			break
+			if target != "" {
+				foundMatch(opts, target, depth)
+				return formatPath(target, opts), nil
+			}
 		}
-		current = parent
-	}
-
-	return "", nil
+		return "", nil
+	}, nil
 }
 
-func findUpMultipleInDir(dir, name string, options *Options, stopAt string, results *[]string) error {
-	current := dir
+// FindUpIn finds a file or directory by walking up parent directories from cwd,
+// using options without modifying them, so one Options can be shared across directories
+func FindUpIn(cwd string, name string, options *Options) (string, error) {
+	return FindUp(name, withCwd(options, cwd))
+}
 
-	for {
-		// Check if we should stop at this directory
-		if stopAt != "" && current == stopAt {
-			break
-		}
+// FindUpOr finds a file or directory by walking up parent directories, returning fallback when nothing is found
+func FindUpOr(name string, fallback string, options *Options) (string, error) {
+	return FindUpOrFunc(name, func() (string, error) { return fallback, nil }, options)
+}
 
-		// Check if the target exists in current directory
-		if isGlobPattern(name) {
-			// Handle glob patterns by listing directory contents
-			entries, err := os.ReadDir(current)
-			if err == nil {
-				for _, entry := range entries {
-					entryName := entry.Name()
-					if matched, err := matchesGlob(entryName, name); err == nil && matched {
-						target := filepath.Join(current, entryName)
-						if matches, err := pathMatches(target, options); err == nil && matches {
-							*results = append(*results, target)
-
-							// Check if we've reached the limit
-							if options.Limit > 0 && len(*results) >= options.Limit {
-								return nil
-							}
-						}
-					}
-				}
-			}
-		} else {
-			// Handle exact filename match
-			target := filepath.Join(current, name)
-			if matches, err := pathMatches(target, options); err == nil && matches {
-				*results = append(*results, target)
-
-				// Check if we've reached the limit
-				if options.Limit > 0 && len(*results) >= options.Limit {
-					return nil
-				}
-			}
-		}
+// FindUpOrFunc finds a file or directory by walking up parent directories,
+// calling fallback to compute the result only when nothing is found
+func FindUpOrFunc(name string, fallback func() (string, error), options *Options) (string, error) {
+	result, err := FindUp(name, options)
+	if err != nil {
+		return "", err
+	}
+	if result == "" {
+		return fallback()
+	}
+	return result, nil
+}
 
-		// Move to parent directory
-		parent := filepath.Dir(current)
-		if parent == current {
-			// Reached root directory
-			break
-		}
-		current = parent
+// IsWithin reports whether Cwd or one of its parent directories contains markerName and returns
+// that directory, e.g. IsWithin(".git", nil) tells whether the working directory is inside a repository
+func IsWithin(markerName string, options *Options) (bool, string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return false, "", err
 	}
+	defer recordElapsed(opts)
 
-	return nil
+	marker, err := findUpInDir(opts.Cwd, markerName, opts, opts.StopAt)
+	if err != nil || marker == "" {
+		return false, "", err
+	}
+	return true, formatPath(filepath.Dir(marker), opts), nil
 }
 
-func findUpWithMatcherInDir(dir string, matcher MatcherFunc, options *Options, stopAt string) (string, error) {
-	current := dir
+// FirstExisting returns the first of paths that exists and matches options, checking them in order,
+// e.g. a fixed list of configuration locations. Relative paths are resolved against Cwd.
+func FirstExisting(paths []string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
 
-	for {
-		// Check if we should stop at this directory
-		if stopAt != "" && current == stopAt {
-			break
+	for _, candidate := range paths {
+		if !filepath.IsAbs(candidate) {
+			candidate = filepath.Join(opts.Cwd, candidate)
 		}
 
-		// Call the matcher function
-		result, shouldStop, err := matcher(current)
+		matches, err := pathMatches(candidate, opts)
 		if err != nil {
 			return "", err
 		}
-
-		if shouldStop {
-			return result, nil
-		}
-
-		// Move to parent directory
-		parent := filepath.Dir(current)
-		if parent == current {
-			// Reached root directory
-			break
+		if matches {
+			foundMatch(opts, candidate, 0)
+			return formatPath(candidate, opts), nil
 		}
-		current = parent
 	}
 
 	return "", nil
 }
 
-func findDownInDir(dir, name string, options *Options, currentDepth int) (string, error) {
-	// Check if we've exceeded the depth limit
-	if options.Depth > 0 && currentDepth > options.Depth {
-		return "", nil
+// FindUpBoundary finds the nearest directory containing a directory named dirName by walking up
+// parent directories and returns that containing directory, e.g. the repository root for ".git"
+func FindUpBoundary(dirName string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
 	}
+	defer recordElapsed(opts)
+	opts.Type = DirectoryType
 
-	// Check if the target exists in current directory
-	if isGlobPattern(name) {
-		// Handle glob patterns by listing directory contents
-		entries, err := os.ReadDir(dir)
-		if err == nil {
-			for _, entry := range entries {
-				entryName := entry.Name()
-				if matched, err := matchesGlob(entryName, name); err == nil && matched {
-					target := filepath.Join(dir, entryName)
-					if matches, err := pathMatches(target, options); err == nil && matches {
-						return target, nil
-					}
-				}
-			}
-		}
-	} else {
-		// Handle exact filename match
-		target := filepath.Join(dir, name)
-		if matches, err := pathMatches(target, options); err == nil && matches {
-			return target, nil
-		}
+	marker, err := findUpInDir(opts.Cwd, dirName, opts, opts.StopAt)
+	if err != nil || marker == "" {
+		return "", err
 	}
+	return formatPath(filepath.Dir(marker), opts), nil
+}
 
-	// Read directory contents
-	entries, err := os.ReadDir(dir)
+// FindUpMultiple finds multiple files or directories by walking up parent directories
+func FindUpMultiple(name string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer recordElapsed(opts)
 
-	// Collect subdirectories
-	var subdirs []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			subdirs = append(subdirs, filepath.Join(dir, entry.Name()))
-		}
+	var results []string
+	err = findUpMultipleInDir(opts.Cwd, name, opts, opts.StopAt, &results)
+	return formatPaths(results, opts), err
+}
+
+// FindUpEach calls fn for each file or directory matching name while walking up parent directories,
+// nearest first, with the number of levels above Cwd it was found at. The walk ends when fn returns
+// stop or an error, which FindUpEach returns.
+func FindUpEach(name string, options *Options, fn func(path string, depth int) (stop bool, err error)) error {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return err
 	}
+	defer recordElapsed(opts)
+
+	return walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		matches, err := withLevelTimeout(opts, func() ([]string, error) {
+			return findInDir(current, name, opts)
+		})
+		if err != nil {
+			return skipDir(opts, current, err)
+		}
+
+		for _, target := range matches {
+			foundMatch(opts, target, depth)
+			if stop, err := fn(formatPath(target, opts), depth); err != nil || stop {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+}
+
+// FindUpOutermost finds the farthest file or directory by walking up parent directories
+// all the way to the root (or StopAt) and returning the last match
+func FindUpOutermost(name string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+
+	// Every level must be visited, so neither the limit nor StopAtFirstLevel apply
+	opts.Limit = 0
+	opts.StopAtFirstLevel = false
+
+	var results []string
+	if err := findUpMultipleInDir(opts.Cwd, name, opts, opts.StopAt, &results); err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+	return formatPath(results[len(results)-1], opts), nil
+}
+
+// FindUpWithMatcher finds a file or directory using a custom matcher function
+func FindUpWithMatcher(matcher MatcherFunc, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+
+	result, err := findUpWithMatcherInDir(opts.Cwd, matcher, opts, opts.StopAt)
+	return formatPath(result, opts), err
+}
+
+// FindUpWithMatcherAny runs matcher on every directory up to StopAt concurrently, at most Concurrency at a time,
+// and returns the match of the nearest directory that matched. Matchers not started yet are skipped once
+// the result is known, those already running are waited for. Use it for expensive matchers where
+// checking the directories one by one would be slow.
+func FindUpWithMatcherAny(matcher MatcherFunc, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+
+	result, err := findUpWithMatcherAnyInDir(opts.Cwd, matcher, opts, opts.StopAt)
+	return formatPath(result, opts), err
+}
+
+// FindUpAny finds the nearest file or directory matching any of names by walking up parent directories.
+// When several names match in the same directory, the earliest name in the list wins.
+func FindUpAny(names []string, options *Options) (Match, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return Match{}, err
+	}
+	defer recordElapsed(opts)
+
+	match, err := findUpAnyInDir(opts.Cwd, names, opts, opts.StopAt)
+	return completeMatch(match, opts, err)
+}
+
+// FindUpAnyMultiple finds the files or directories matching any of names by walking up parent directories
+// once, and returns their matches grouped by name in the order of names, each group nearest first. Every
+// name has a group, with no paths when nothing matched it. Limit applies to each name separately.
+// A directory that cannot be searched is skipped for every name and reported to OnError once.
+func FindUpAnyMultiple(names []string, options *Options) ([]NameMatches, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	groups := make([]NameMatches, len(names))
+	for i, name := range names {
+		groups[i].Name = name
+	}
+
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		// Note the names still to look up, as the lookup may outlive a level timeout and must
+		// not read the groups while they are being filled in
+		pending := make([]bool, len(groups))
+		for i, group := range groups {
+			pending[i] = opts.Limit <= 0 || len(group.Paths) < opts.Limit
+		}
+
+		// Look up every name in the level at once, so a failing directory is skipped and reported once
+		levelMatches, err := withLevelTimeout(opts, func() ([][]string, error) {
+			levelMatches := make([][]string, len(names))
+			for i, name := range names {
+				if !pending[i] {
+					continue
+				}
+				matches, err := findInDir(current, name, opts)
+				if err != nil {
+					return nil, err
+				}
+				levelMatches[i] = matches
+			}
+			return levelMatches, nil
+		})
+		if err != nil {
+			return skipDir(opts, current, err)
+		}
+
+		for i, matches := range levelMatches {
+			group := &groups[i]
+			for _, target := range matches {
+				foundMatch(opts, target, depth)
+				group.Paths = append(group.Paths, formatPath(target, opts))
+				if opts.Limit > 0 && len(group.Paths) >= opts.Limit {
+					break
+				}
+			}
+		}
+		return false, nil
+	})
+	return groups, err
+}
+
+// FindUpResult finds a file or directory by walking up parent directories like FindUp and returns it
+// as a Result, with the other entries in its directory when IncludeSiblings is set
+func FindUpResult(name string, options *Options) (Result, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return Result{}, err
+	}
+	defer recordElapsed(opts)
+
+	target, err := findUpInDir(opts.Cwd, name, opts, opts.StopAt)
+	if err != nil || target == "" {
+		return Result{}, err
+	}
+
+	var result Result
+	if opts.IncludeSiblings {
+		entries, err := readDir(opts, filepath.Dir(target))
+		if err != nil {
+			return Result{}, err
+		}
+		for _, entry := range entries {
+			if entry.Name() != filepath.Base(target) {
+				result.Siblings = append(result.Siblings, entry.Name())
+			}
+		}
+	}
+
+	result.Match, err = completeMatch(Match{Path: target, MatchedName: name}, opts, nil)
+	return result, err
+}
+
+// FindUpAnySet finds the nearest file or directory whose name is in names by walking up parent directories.
+// Each directory is listed once and its entries looked up in the set, which is faster than FindUpAny
+// for many candidate names. Names are matched exactly, and when several match in the same directory
+// the first in name order wins.
+func FindUpAnySet(names map[string]struct{}, options *Options) (Match, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return Match{}, err
+	}
+	defer recordElapsed(opts)
+
+	match, err := findUpAnySetInDir(opts.Cwd, names, opts, opts.StopAt)
+	return completeMatch(match, opts, err)
+}
+
+// completeMatch fills in the fields of match derived from its path and formats the path
+func completeMatch(match Match, options *Options, err error) (Match, error) {
+	if match.Path != "" && options.StopAt != "" {
+		match.DepthFromStopAt = depthBelow(filepath.Dir(match.Path), options.StopAt)
+	}
+	if match.Path != "" && err == nil && options.GitStatus {
+		match.GitStatus, err = gitStatusOf(match.Path)
+	}
+	match.Path = formatPath(match.Path, options)
+	return match, err
+}
+
+// FindDown finds a file or directory by walking down descendant directories
+func FindDown(name string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+
+	if opts.IncludeSelf && opts.StartDepth >= opts.MinDepth {
+		if match, ok := selfMatch(opts.Cwd, name, opts); ok {
+			return formatPath(match.Path, opts), nil
+		}
+	}
+
+	result, err := findDownInDir(opts.Cwd, name, opts, opts.StartDepth)
+	return formatPath(result, opts), err
+}
+
+// FindDownIn finds a file or directory by walking down descendant directories of cwd,
+// using options without modifying them, so one Options can be shared across directories
+func FindDownIn(cwd string, name string, options *Options) (string, error) {
+	return FindDown(name, withCwd(options, cwd))
+}
+
+// FindDownMultiple finds multiple files or directories by walking down descendant directories
+func FindDownMultiple(name string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	var matches []EntryMatch
+	root := walkFrame{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}
+	if opts.DownConcurrency > 1 {
+		err = walkDownMultipleConcurrent(root, name, opts, &matches)
+	} else {
+		_, err = walkDownMultiple([]walkFrame{root}, name, opts, &matches)
+	}
+
+	var results []string
+	for _, match := range matches {
+		results = append(results, formatPath(match.Path, opts))
+	}
+	return results, err
+}
+
+// FindDownMultipleEntries finds multiple files or directories by walking down descendant directories
+// and returns them with their directory entries
+func FindDownMultipleEntries(name string, options *Options) ([]EntryMatch, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	var results []EntryMatch
+	_, err = walkDownMultiple([]walkFrame{{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}}, name, opts, &results)
+	for i := range results {
+		results[i].Path = formatPath(results[i].Path, opts)
+	}
+	return results, err
+}
+
+// FindDownUniqueNames finds files or directories matching pattern by walking down descendant directories
+// and returns their distinct base names in sorted order. Limit caps the number of distinct names.
+func FindDownUniqueNames(pattern string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	names := make(map[string]struct{})
+	_, err = walkDown([]walkFrame{{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}}, pattern, opts, func(match EntryMatch) (bool, bool) {
+		names[filepath.Base(match.Path)] = struct{}{}
+
+		// Check if we've reached the limit
+		return true, opts.Limit <= 0 || len(names) < opts.Limit
+	})
+
+	results := make([]string, 0, len(names))
+	for name := range names {
+		results = append(results, name)
+	}
+	sort.Strings(results)
+	return results, err
+}
+
+// FindDownDepthHistogram counts the matches for name by walking down descendant directories,
+// keyed by the depth below Cwd of the directory they are in, 0 being Cwd itself
+func FindDownDepthHistogram(name string, options *Options) (map[int]int, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	var matches []EntryMatch
+	_, err = walkDownMultiple([]walkFrame{{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}}, name, opts, &matches)
+
+	histogram := make(map[int]int)
+	for _, match := range matches {
+		histogram[match.Depth-opts.StartDepth]++
+	}
+	return histogram, err
+}
+
+// FindDownWithDepthMatcher finds a file or directory by walking down descendant directories
+// and calling matcher with each directory and its depth below Cwd
+func FindDownWithDepthMatcher(matcher DepthMatcherFunc, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+
+	result, err := findDownWithDepthMatcherInDir(opts.Cwd, matcher, opts)
+	return formatPath(result, opts), err
+}
+
+// FindDownNearestLevel walks down descendant directories breadth-first, level by level, and returns
+// every match at the shallowest level that has any, e.g. all "*.md" files of the nearest documented directories
+func FindDownNearestLevel(pattern string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	results, err := findDownNearestLevelInDir(opts.Cwd, pattern, opts)
+	return formatPaths(results, opts), err
+}
+
+// FindUpSiblings finds the nearest directory containing markerName by walking up parent directories
+// and returns the entries in that directory matching siblingPattern
+func FindUpSiblings(markerName string, siblingPattern string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	marker, err := findUpInDir(opts.Cwd, markerName, opts, opts.StopAt)
+	if err != nil || marker == "" {
+		return nil, err
+	}
+
+	results, err := findInDir(filepath.Dir(marker), siblingPattern, opts)
+	return formatPaths(results, opts), err
+}
+
+// FindUpThenDown finds the nearest directory containing markerName by walking up parent directories,
+// then returns the matches for pattern found by walking down from that directory, e.g. every
+// package.json below the workspace root. Set RelativeToMatch to get them relative to that directory.
+func FindUpThenDown(markerName string, pattern string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	marker, err := findUpInDir(opts.Cwd, markerName, opts, opts.StopAt)
+	if err != nil || marker == "" {
+		return nil, err
+	}
+
+	root := filepath.Dir(marker)
+	downOpts := *opts
+	downOpts.Cwd = root
+
+	var matches []EntryMatch
+	_, err = walkDownMultiple([]walkFrame{{Dir: root, Depth: opts.StartDepth, Root: true}}, pattern, &downOpts, &matches)
+
+	var results []string
+	for _, match := range matches {
+		if !opts.RelativeToMatch {
+			results = append(results, formatPath(match.Path, opts))
+			continue
+		}
+
+		result := match.Path
+		if rel, relErr := filepath.Rel(root, result); relErr == nil {
+			result = rel
+		}
+		if opts.ForwardSlashes {
+			result = filepath.ToSlash(result)
+		}
+		results = append(results, result)
+	}
+	return results, err
+}
+
+// FindBoth finds the files or directories matching name both in parent directories and in descendant
+// directories of Cwd, in one list tagged with the direction and signed depth they were found at.
+// Ancestor matches come first, nearest first, followed by descendant matches in walk order; matches
+// in Cwd itself are reported once as Up with depth 0. Limit applies to each direction separately.
+func FindBoth(name string, options *Options) ([]Match, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	var results []Match
+	seen := map[string]bool{}
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		matches, err := withLevelTimeout(opts, func() ([]string, error) {
+			return findInDir(current, name, opts)
+		})
+		if err != nil {
+			return skipDir(opts, current, err)
+		}
+
+		for _, target := range matches {
+			foundMatch(opts, target, depth)
+			seen[target] = true
+			results = append(results, Match{Path: target, MatchedName: name, Direction: Up, Depth: -depth})
+			if opts.Limit > 0 && len(seen) >= opts.Limit {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []EntryMatch
+	_, walkErr := walkDownMultiple([]walkFrame{{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}}, name, opts, &entries)
+	for _, entry := range entries {
+		if !seen[entry.Path] {
+			results = append(results, Match{Path: entry.Path, MatchedName: name, Direction: Down, Depth: entry.Depth})
+		}
+	}
+
+	for i := range results {
+		if results[i], err = completeMatch(results[i], opts, nil); err != nil {
+			return nil, err
+		}
+	}
+	return results, walkErr
+}
+
+// AncestorDirs returns Cwd and its parent directories in root-first order, ending with Cwd,
+// which suits layering config files so nearer ones override farther ones. The list starts
+// at the filesystem root, or below StopAt when it is set, as StopAt is never visited.
+func AncestorDirs(options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	var results []string
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		results = append(results, current)
+		return false, nil
+	})
+
+	// Reverse the nearest-first walk into root-first order
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+
+	return formatPaths(results, opts), err
+}
+
+// FindUpByStem finds a file or directory whose name without its extension equals stem
+// by walking up parent directories, e.g. "config" matches "config.json" or "config.yaml"
+func FindUpByStem(stem string, options *Options) (string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", err
+	}
+	defer recordElapsed(opts)
+
+	var result string
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		matches, err := matchingEntries(current, opts, stemMatcher(stem))
+		if err != nil {
+			return skipDir(opts, current, err)
+		}
+		if len(matches) > 0 {
+			result = preferredMatch(matches, opts)
+			foundMatch(opts, result, depth)
+			return true, nil
+		}
+		return false, nil
+	})
+
+	return formatPath(result, opts), err
+}
+
+// FindUpByStemMultiple finds every file or directory whose name without its extension
+// equals stem by walking up parent directories
+func FindUpByStemMultiple(stem string, options *Options) ([]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	var results []string
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		matches, err := matchingEntries(current, opts, stemMatcher(stem))
+		if err != nil {
+			return skipDir(opts, current, err)
+		}
+		for _, match := range matches {
+			foundMatch(opts, match, depth)
+			results = append(results, match)
+
+			// Check if we've reached the limit
+			if opts.Limit > 0 && len(results) >= opts.Limit {
+				return true, nil
+			}
+		}
+		return opts.StopAtFirstLevel && len(matches) > 0, nil
+	})
+
+	return formatPaths(results, opts), err
+}
+
+// IsGlob reports whether name is treated as a glob pattern by the find functions
+func IsGlob(name string) bool {
+	return strings.Contains(name, "*") || strings.Contains(name, "?") || strings.Contains(name, "[")
+}
+
+// EscapeGlob escapes the glob metacharacters in name so it only matches itself.
+// Names without metacharacters are returned unchanged since they are matched literally.
+func EscapeGlob(name string) string {
+	if !IsGlob(name) {
+		return name
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch r {
+		case '*', '?', '[':
+			if runtime.GOOS == "windows" {
+				// Backslash is a path separator on Windows, so use a character class
+				b.WriteByte('[')
+				b.WriteRune(r)
+				b.WriteByte(']')
+				continue
+			}
+			b.WriteByte('\\')
+		case '\\':
+			if runtime.GOOS != "windows" {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// FindUpRegexpCapture finds a file or directory whose name matches re by walking up parent directories
+// and returns it with the values of the named capture groups of re, e.g. `config\.(?P<env>\w+)\.json`
+func FindUpRegexpCapture(re *regexp.Regexp, options *Options) (string, map[string]string, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return "", nil, err
+	}
+	defer recordElapsed(opts)
+
+	var result string
+	err = walkUp(opts.Cwd, opts.StopAt, opts, func(current string, depth int) (bool, error) {
+		matches, err := matchingEntries(current, opts, re.MatchString)
+		if err != nil {
+			return skipDir(opts, current, err)
+		}
+		if len(matches) > 0 {
+			result = preferredMatch(matches, opts)
+			foundMatch(opts, result, depth)
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil || result == "" {
+		return "", nil, err
+	}
+
+	// Extract the named groups from the matched name
+	captures := make(map[string]string)
+	submatches := re.FindStringSubmatch(filepath.Base(result))
+	for i, group := range re.SubexpNames() {
+		if group != "" && i < len(submatches) {
+			captures[group] = submatches[i]
+		}
+	}
+
+	return formatPath(result, opts), captures, nil
+}
+
+// Helper functions
+
+// matchesGlob checks if a file matches a glob pattern
+func matchesGlob(filename, pattern string) (bool, error) {
+	matched, err := filepath.Match(pattern, filename)
+	return matched, err
+}
+
+// matchesAny reports whether name matches any of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := matchesGlob(name, pattern); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// readPatterns reads the glob patterns in file, one per line, skipping blank lines and "#" comments
+func readPatterns(file, cwd string) ([]string, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := matchesGlob("", line); err != nil {
+			return nil, fmt.Errorf("findup: invalid pattern %q in %s: %w", line, file, err)
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// findInDir returns the entries of dir matching name, which may be a glob pattern
+func findInDir(dir, name string, options *Options) ([]string, error) {
+	var results []string
+	name = targetName(dir, name, options)
+
+	if match := listMatcher(dir, name, options); match != nil {
+		return matchingEntries(dir, options, match)
+	}
+
+	// Handle exact filename match
+	target := filepath.Join(dir, name)
+	matches, err := pathMatches(target, options)
+	if err != nil {
+		return nil, err
+	}
+	if matches {
+		results = append(results, target)
+	}
+	return results, nil
+}
+
+// listMatcher returns the function matching entry names when finding name in dir requires listing dir,
+// or nil when name is matched exactly
+func listMatcher(dir, name string, options *Options) func(entryName string) bool {
+	switch {
+	case options.NameMatch != nil:
+		return options.NameMatch
+	case options.RootAnchoredGlob:
+		return anchoredMatcher(dir, name, options.StopAt)
+	case IsGlob(name), options.NormalizeUnicode:
+		return nameMatcher(name, options)
+	}
+	return nil
+}
+
+// nameMatcher returns the function matching entry names against the glob pattern name,
+// or options.NameMatch when it is set
+func nameMatcher(name string, options *Options) func(entryName string) bool {
+	if options.NameMatch != nil {
+		return options.NameMatch
+	}
+
+	// Fold case only for glob patterns so exact names keep matching exactly
+	foldCase := options.GlobCaseInsensitive && IsGlob(name)
+	skipDotfiles := options.SkipDotfiles && !strings.HasPrefix(name, ".")
+
+	pattern := name
+	if options.NormalizeUnicode {
+		pattern = norm.NFC.String(pattern)
+	}
+	if foldCase {
+		pattern = strings.ToLower(pattern)
+	}
+
+	return func(entryName string) bool {
+		if skipDotfiles && strings.HasPrefix(entryName, ".") {
+			return false
+		}
+		if options.NormalizeUnicode {
+			entryName = norm.NFC.String(entryName)
+		}
+		if foldCase {
+			entryName = strings.ToLower(entryName)
+		}
+		matched, err := matchesGlob(entryName, pattern)
+		return err == nil && matched
+	}
+}
+
+// anchoredMatcher returns a name matcher matching the slash-separated path of the entries of dir
+// relative to stopAt against pattern
+func anchoredMatcher(dir, pattern, stopAt string) func(entryName string) bool {
+	return func(entryName string) bool {
+		rel, err := filepath.Rel(stopAt, filepath.Join(dir, entryName))
+		if err != nil {
+			return false
+		}
+		matched, err := path.Match(pattern, filepath.ToSlash(rel))
+		return err == nil && matched
+	}
+}
+
+// targetName returns the name to find in dir, computed by options.NameFunc when it is set
+func targetName(dir, name string, options *Options) string {
+	if options.NameFunc != nil {
+		name = options.NameFunc(dir)
+	}
+	return trimTrailingSeparators(name)
+}
+
+// trimTrailingSeparators removes trailing separators from name so "node_modules/" matches like "node_modules"
+func trimTrailingSeparators(name string) string {
+	trimmed := strings.TrimRight(name, "/"+string(filepath.Separator))
+	if trimmed == "" {
+		return name
+	}
+	return trimmed
+}
+
+// firstInDir returns the preferred entry of dir matching name, or an empty string
+func firstInDir(dir, name string, options *Options) (string, error) {
+	// Stop reading large directories at the first match
+	if options.MaxEntriesPerDir > 0 && options.ReadDirFunc == nil {
+		if match := listMatcher(dir, targetName(dir, name, options), options); match != nil {
+			return firstMatchingEntry(dir, options, match)
+		}
+	}
+
+	matches, err := findInDir(dir, name, options)
+	if err != nil || len(matches) == 0 {
+		return "", err
+	}
+	return preferredMatch(matches, options), nil
+}
+
+// preferredMatch picks the match a single-result search returns among the matches in one directory
+func preferredMatch(matches []string, options *Options) string {
+	if options.Type == BothType && options.BothTypePreference != NoPreference {
+		matches = orderByType(matches, options)
+	}
+
+	if options.PreferRealFiles {
+		for _, match := range matches {
+			if info, err := lstat(options, match); err == nil && info.Mode()&os.ModeSymlink == 0 {
+				return match
+			}
+		}
+	}
+
+	return matches[0]
+}
+
+// orderByType returns matches with the type favored by BothTypePreference first, keeping the order within each type
+func orderByType(matches []string, options *Options) []string {
+	var preferred, others []string
+	for _, match := range matches {
+		info, err := stat(options, match)
+		isDir := err == nil && info.IsDir()
+		if isDir == (options.BothTypePreference == DirsFirst) {
+			preferred = append(preferred, match)
+		} else {
+			others = append(others, match)
+		}
+	}
+	return append(preferred, others...)
+}
+
+// matchingEntries returns the entries of dir whose name satisfies match and whose path matches options
+func matchingEntries(dir string, options *Options, match func(entryName string) bool) ([]string, error) {
+	entries, err := readDir(options, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []string
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if !match(entryName) {
+			continue
+		}
+
+		target := filepath.Join(dir, entryName)
+		if matches, err := pathMatches(target, options); err == nil && matches {
+			results = append(results, target)
+		}
+	}
+
+	return results, nil
+}
+
+// stemMatcher returns a name matcher comparing names without their extension to stem
+func stemMatcher(stem string) func(entryName string) bool {
+	return func(entryName string) bool {
+		return strings.TrimSuffix(entryName, filepath.Ext(entryName)) == stem
+	}
+}
+
+// walkUp calls visit for dir and each of its parents, nearest first, until visit
+// asks to stop, the root directory is reached or stopAt is hit
+func walkUp(dir string, stopAt string, options *Options, visit func(current string, depth int) (bool, error)) error {
+	current := dir
+
+	// Resolve StopAt once to compare the resolved directories against it
+	resolvedStopAt := ""
+	if options.ConfineSymlinks && stopAt != "" {
+		resolvedStopAt = stopAt
+		if resolved, err := filepath.EvalSymlinks(stopAt); err == nil {
+			resolvedStopAt = resolved
+		}
+	}
+
+	for depth := 0; ; depth++ {
+		// Check if we should stop at this directory
+		if (stopAt != "" && current == stopAt) || slices.Contains(options.StopAtAny, current) {
+			break
+		}
+
+		// Check if symbolic links lead this directory out of StopAt
+		if resolvedStopAt != "" {
+			if resolved, err := filepath.EvalSymlinks(current); err == nil && !isWithin(resolved, resolvedStopAt) {
+				logDebug(options, "escaped stop directory", current, depth)
+				break
+			}
+		}
+
+		enterDir(options, current, depth)
+		if stop, err := visit(current, depth); err != nil || stop {
+			return err
+		}
+
+		// Stop after a directory containing a marker
+		if containsMarker(options, current) {
+			logDebug(options, "stop marker", current, depth)
+			break
+		}
+
+		// Move to parent directory
+		parent := filepath.Dir(current)
+		if parent == current {
+			// Reached root directory
+			break
+		}
+		current = parent
+	}
+
+	return nil
+}
+
+// containsMarker reports whether dir contains any of options.StopAtMarkers
+func containsMarker(options *Options, dir string) bool {
+	for _, marker := range options.StopAtMarkers {
+		if _, err := lstat(options, filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir hands an error that made the walk skip dir to options.OnError,
+// stopping the walk with the error it returns
+func skipDir(options *Options, dir string, err error) (bool, error) {
+	if options.OnError == nil {
+		return false, nil
+	}
+	if err := options.OnError(dir, err); err != nil {
+		return true, err
+	}
+	return false, nil
+}
+
+func findUpInDir(dir, name string, options *Options, stopAt string) (string, error) {
+	var result string
+
+	err := walkUp(dir, stopAt, options, func(current string, depth int) (bool, error) {
+		// Check if the target exists in current directory
+		target, err := withLevelTimeout(options, func() (string, error) {
+			return firstInDir(current, name, options)
+		})
+		if err != nil {
+			return skipDir(options, current, err)
+		}
+
+		if target != "" {
+			foundMatch(options, target, depth)
+			result = target
+			return true, nil
+		}
+
+		return false, nil
+	})
+
+	return result, err
+}
+
+func findUpMultipleInDir(dir, name string, options *Options, stopAt string, results *[]string) error {
+	return walkUp(dir, stopAt, options, func(current string, depth int) (bool, error) {
+		// Check if the target exists in current directory
+		matches, err := withLevelTimeout(options, func() ([]string, error) {
+			return findInDir(current, name, options)
+		})
+		if err != nil {
+			return skipDir(options, current, err)
+		}
+
+		for _, target := range matches {
+			foundMatch(options, target, depth)
+			*results = append(*results, target)
+
+			// Check if we've reached the limit
+			if options.Limit > 0 && len(*results) >= options.Limit {
+				return true, nil
+			}
+		}
+
+		// Stop climbing after the nearest level with a match
+		return options.StopAtFirstLevel && len(matches) > 0, nil
+	})
+}
+
+func findUpAnyInDir(dir string, names []string, options *Options, stopAt string) (Match, error) {
+	var match Match
+
+	err := walkUp(dir, stopAt, options, func(current string, depth int) (bool, error) {
+		// Check the names in priority order
+		found, err := withLevelTimeout(options, func() (Match, error) {
+			for _, name := range names {
+				target, err := firstInDir(current, name, options)
+				if err != nil {
+					return Match{}, err
+				}
+				if target != "" {
+					return Match{Path: target, MatchedName: name}, nil
+				}
+			}
+			return Match{}, nil
+		})
+		if err != nil {
+			return skipDir(options, current, err)
+		}
+
+		if found.Path != "" {
+			foundMatch(options, found.Path, depth)
+			match = found
+			return true, nil
+		}
+
+		return false, nil
+	})
+
+	return match, err
+}
+
+func findUpAnySetInDir(dir string, names map[string]struct{}, options *Options, stopAt string) (Match, error) {
+	var match Match
+
+	err := walkUp(dir, stopAt, options, func(current string, depth int) (bool, error) {
+		// List the directory once and look each entry up in the set
+		found, err := withLevelTimeout(options, func() (Match, error) {
+			entries, err := readDir(options, current)
+			if err != nil {
+				return Match{}, err
+			}
+			for _, entry := range entries {
+				if _, ok := names[entry.Name()]; !ok {
+					continue
+				}
+				target := filepath.Join(current, entry.Name())
+				matches, err := pathMatches(target, options)
+				if err != nil {
+					return Match{}, err
+				}
+				if matches {
+					return Match{Path: target, MatchedName: entry.Name()}, nil
+				}
+			}
+			return Match{}, nil
+		})
+		if err != nil {
+			return skipDir(options, current, err)
+		}
+
+		if found.Path != "" {
+			foundMatch(options, found.Path, depth)
+			match = found
+			return true, nil
+		}
+
+		return false, nil
+	})
+
+	return match, err
+}
+
+func findUpWithMatcherInDir(dir string, matcher MatcherFunc, options *Options, stopAt string) (string, error) {
+	var result string
+
+	err := walkUp(dir, stopAt, options, func(current string, depth int) (bool, error) {
+		// Call the matcher function
+		outcome, err := withLevelTimeout(options, func() (matcherResult, error) {
+			matched, shouldStop, err := callMatcher(matcher, current, options.MatcherCache)
+			return matcherResult{path: matched, shouldStop: shouldStop}, err
+		})
+		if err == ErrLevelTimeout {
+			return skipDir(options, current, err)
+		}
+		if err != nil {
+			return true, err
+		}
+
+		matched, shouldStop := outcome.path, outcome.shouldStop
+
+		if shouldStop {
+			foundMatch(options, matched, depth)
+			result = matched
+		}
+		return shouldStop, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// callMatcher calls matcher for directory, consulting and filling cache when it is set
+func callMatcher(matcher MatcherFunc, directory string, cache *MatcherCache) (string, bool, error) {
+	if cache == nil {
+		return matcher(directory)
+	}
+
+	cache.mu.Lock()
+	cached, ok := cache.results[directory]
+	cache.mu.Unlock()
+	if ok {
+		return cached.path, cached.shouldStop, nil
+	}
+
+	result, shouldStop, err := matcher(directory)
+	if err != nil {
+		return "", false, err
+	}
+
+	cache.mu.Lock()
+	if cache.results == nil {
+		cache.results = make(map[string]matcherResult)
+	}
+	cache.results[directory] = matcherResult{path: result, shouldStop: shouldStop}
+	cache.mu.Unlock()
+
+	return result, shouldStop, nil
+}
+
+func findDownInDir(dir, name string, options *Options, currentDepth int) (string, error) {
+	// Check if we've exceeded the depth limit
+	if options.Depth >= 0 && currentDepth > options.Depth {
+		reportPrune(options, dir, currentDepth, "depth")
+		return "", nil
+	}
+	enterDir(options, dir, currentDepth)
+	if err := checkAbort(options, dir); err != nil {
+		return "", err
+	}
+
+	// Check if the target exists in current directory
+	if currentDepth >= options.MinDepth {
+		if target, _ := firstInDir(dir, name, options); target != "" {
+			foundMatch(options, target, currentDepth)
+			return target, nil
+		}
+	}
+
+	// Read directory contents
+	entries, err := readDir(options, dir)
+	if err != nil {
+		return "", err
+	}
+
+	// Collect subdirectories
+	subdirs := collectSubdirs(dir, entries, options, currentDepth+1)
 
 	// Search subdirectories based on strategy
 	if options.Strategy == BreadthFirst {
 		// Breadth-first: search all subdirectories at current level first
 		for _, subdir := range subdirs {
-			if result, err := findDownInDir(subdir, name, options, currentDepth+1); err == nil && result != "" {
+			result, err := findDownInDir(subdir, name, options, currentDepth+1)
+			if errors.Is(err, ErrAborted) {
+				return "", err
+			}
+			if err == nil && result != "" {
 				return result, nil
 			}
 		}
 	} else {
 		// Depth-first: search each subdirectory completely before moving to next
 		for _, subdir := range subdirs {
-			if result, err := findDownInDir(subdir, name, options, currentDepth+1); err == nil && result != "" {
+			result, err := findDownInDir(subdir, name, options, currentDepth+1)
+			if errors.Is(err, ErrAborted) {
+				return "", err
+			}
+			if err == nil && result != "" {
 				return result, nil
 			}
 		}
@@ -393,28 +1860,283 @@ func findDownInDir(dir, name string, options *Options, currentDepth int) (string
 	return "", nil
 }
 
-func findDownMultipleInDir(dir, name string, options *Options, currentDepth int, results *[]string) error {
-	// Check if we've exceeded the depth limit
-	if options.Depth > 0 && currentDepth > options.Depth {
+func findDownWithDepthMatcherInDir(dir string, matcher DepthMatcherFunc, options *Options) (string, error) {
+	stack := []walkFrame{{Dir: dir, Depth: options.StartDepth, Root: true}}
+
+	for len(stack) > 0 {
+		frame := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// Check if we've exceeded the depth limit
+		if options.Depth >= 0 && frame.Depth > options.Depth {
+			reportPrune(options, frame.Dir, frame.Depth, "depth")
+			continue
+		}
+		enterDir(options, frame.Dir, frame.Depth)
+		if err := checkAbort(options, frame.Dir); err != nil {
+			return "", err
+		}
+
+		// Call the matcher function
+		result, shouldStop, err := matcher(frame.Dir, frame.Depth)
+		if err != nil {
+			return "", err
+		}
+		if shouldStop {
+			foundMatch(options, result, frame.Depth)
+			return result, nil
+		}
+
+		// Read directory contents, only failing for the starting directory
+		entries, err := readDir(options, frame.Dir)
+		if err != nil {
+			if frame.Root {
+				return "", err
+			}
+			continue
+		}
+
+		// Queue subdirectories in reverse so the first one is searched next
+		subdirs := collectSubdirs(frame.Dir, entries, options, frame.Depth+1)
+		for i := len(subdirs) - 1; i >= 0; i-- {
+			stack = append(stack, walkFrame{Dir: subdirs[i], Depth: frame.Depth + 1})
+		}
+	}
+
+	return "", nil
+}
+
+func findDownNearestLevelInDir(dir, pattern string, options *Options) ([]string, error) {
+	level := []string{dir}
+
+	for depth := options.StartDepth; len(level) > 0; depth++ {
+		// Check if we've exceeded the depth limit
+		if options.Depth >= 0 && depth > options.Depth {
+			for _, current := range level {
+				reportPrune(options, current, depth, "depth")
+			}
+			break
+		}
+
+		var results, next []string
+		for _, current := range level {
+			enterDir(options, current, depth)
+			if err := checkAbort(options, current); err != nil {
+				return results, err
+			}
+
+			// Check if the target exists in current directory
+			if depth >= options.MinDepth {
+				matches, _ := findInDir(current, pattern, options)
+				if depth == options.StartDepth && options.IncludeSelf {
+					if match, ok := selfMatch(current, pattern, options); ok {
+						matches = append([]string{match.Path}, matches...)
+					}
+				}
+				if options.PerDirLimit > 0 && len(matches) > options.PerDirLimit {
+					matches = matches[:options.PerDirLimit]
+				}
+				for _, match := range matches {
+					foundMatch(options, match, depth)
+				}
+				results = append(results, matches...)
+			}
+
+			// Read directory contents, only failing for the starting directory
+			entries, err := readDir(options, current)
+			if err != nil {
+				if depth == options.StartDepth {
+					return nil, err
+				}
+				continue
+			}
+			next = append(next, collectSubdirs(current, entries, options, depth+1)...)
+		}
+
+		// Stop at the first level with any match
+		if len(results) > 0 {
+			return results, nil
+		}
+		level = next
+	}
+
+	return nil, nil
+}
+
+// walkFrame is a directory waiting to be searched by walkDownMultiple
+type walkFrame struct {
+	// Dir is the directory to search
+	Dir string `json:"dir"`
+	// Depth is the depth of Dir below the starting directory
+	Depth int `json:"depth"`
+	// Skip is the number of matches in Dir that were already returned
+	Skip int `json:"skip,omitempty"`
+	// Root marks the starting directory of the walk
+	Root bool `json:"root,omitempty"`
+}
+
+// walkDownMultiple searches the directories on stack depth-first, appending matches to results.
+// When the limit is reached it returns the frames still to be searched so the walk can be resumed.
+func walkDownMultiple(stack []walkFrame, name string, options *Options, results *[]EntryMatch) ([]walkFrame, error) {
+	return collectMatches(options, results, func(emit func(EntryMatch) (bool, bool)) ([]walkFrame, error) {
+		return walkDown(stack, name, options, emit)
+	})
+}
+
+// collectMatches runs walk, appending the matches it emits to results until Limit, MaxResults or
+// MaxTotalBytes is reached. A match refused for exceeding MaxResults or MaxTotalBytes is not taken,
+// so a resumed walk returns it first, except for a first match exceeding MaxTotalBytes on its own,
+// which is passed over so paging moves on.
+func collectMatches(options *Options, results *[]EntryMatch, walk func(emit func(EntryMatch) (bool, bool)) ([]walkFrame, error)) ([]walkFrame, error) {
+	tooMany := false
+	var totalBytes int64
+	remaining, err := walk(func(match EntryMatch) (bool, bool) {
+		// Skip matches returned by a previous scan
+		if options.Seen != nil {
+			if _, ok := options.Seen[match.Path]; ok {
+				return true, true
+			}
+		}
+
+		// Check if the match would exceed the maximum
+		if options.MaxResults > 0 && len(*results) >= options.MaxResults {
+			tooMany = true
+			return false, false
+		}
+
+		// Check if the match would exceed the byte budget
+		overBudget := false
+		if options.MaxTotalBytes > 0 {
+			totalBytes += entrySize(match, options)
+			if overBudget = totalBytes > options.MaxTotalBytes; overBudget && !options.IncludeOverBudget {
+				return len(*results) == 0, false
+			}
+		}
+
+		if options.Seen != nil {
+			options.Seen[match.Path] = struct{}{}
+		}
+		*results = append(*results, match)
+
+		// Check if we've reached the limit
+		return true, !overBudget && (options.Limit <= 0 || len(*results) < options.Limit)
+	})
+
+	if tooMany {
+		if err != nil {
+			return remaining, errors.Join(ErrTooManyResults, err)
+		}
+		return remaining, ErrTooManyResults
+	}
+	return remaining, err
+}
+
+// entrySize returns the size of the match from its directory entry, or 0 when it cannot be read
+func entrySize(match EntryMatch, options *Options) int64 {
+	if match.Entry != nil {
+		if info, err := match.Entry.Info(); err == nil {
+			return info.Size()
+		}
+	}
+	if info, err := lstat(options, match.Path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// walkDown searches the directories on stack depth-first, passing each match to emit, which reports
+// whether it took the match and whether the walk goes on. When it stops the frames still to be searched
+// are returned, starting with the match emit did not take, if any.
+func walkDown(stack []walkFrame, name string, options *Options, emit func(EntryMatch) (taken, more bool)) ([]walkFrame, error) {
+	var errs []error
+
+	for len(stack) > 0 {
+		frame := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// Check if we've exceeded the depth limit
+		if options.Depth >= 0 && frame.Depth > options.Depth {
+			reportPrune(options, frame.Dir, frame.Depth, "depth")
+			continue
+		}
+		enterDir(options, frame.Dir, frame.Depth)
+		if err := checkAbort(options, frame.Dir); err != nil {
+			return nil, errors.Join(append(errs, err)...)
+		}
+
+		// Check if the target exists in current directory
+		var matches []EntryMatch
+		if frame.Depth >= options.MinDepth {
+			matches = findEntriesInDir(frame.Dir, name, options)
+			if frame.Root && options.IncludeSelf {
+				if match, ok := selfMatch(frame.Dir, name, options); ok {
+					matches = append([]EntryMatch{match}, matches...)
+				}
+			}
+		}
+		if options.PerDirLimit > 0 && len(matches) > options.PerDirLimit {
+			matches = matches[:options.PerDirLimit]
+		}
+		for i := frame.Skip; i < len(matches); i++ {
+			foundMatch(options, matches[i].Path, frame.Depth)
+			matches[i].Depth = frame.Depth
+			if taken, more := emit(matches[i]); !more {
+				frame.Skip = i
+				if taken {
+					frame.Skip = i + 1
+				}
+				return append(stack, frame), errors.Join(errs...)
+			}
+		}
+
+		// Read directory contents
+		entries, err := readDir(options, frame.Dir)
+		if err != nil {
+			if options.CollectErrors {
+				errs = append(errs, err)
+				continue
+			}
+			return nil, err
+		}
+
+		// Queue subdirectories in reverse so the first one is searched next
+		subdirs := collectSubdirs(frame.Dir, entries, options, frame.Depth+1)
+		for i := len(subdirs) - 1; i >= 0; i-- {
+			stack = append(stack, walkFrame{Dir: subdirs[i], Depth: frame.Depth + 1})
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// checkAbort returns ErrAborted when dir contains AbortOnFile
+func checkAbort(options *Options, dir string) error {
+	if options.AbortOnFile == "" {
 		return nil
 	}
+	tripwire := filepath.Join(dir, options.AbortOnFile)
+	if _, err := lstat(options, tripwire); err == nil {
+		return fmt.Errorf("%w: found %s", ErrAborted, tripwire)
+	}
+	return nil
+}
 
-	// Check if the target exists in current directory
-	if isGlobPattern(name) {
+// findEntriesInDir returns the matches for name in dir along with their directory entries
+func findEntriesInDir(dir, name string, options *Options) []EntryMatch {
+	var matches []EntryMatch
+	name = targetName(dir, name, options)
+
+	if options.NameMatch != nil || options.NormalizeUnicode || IsGlob(name) {
 		// Handle glob patterns by listing directory contents
-		entries, err := os.ReadDir(dir)
+		entries, err := readDir(options, dir)
 		if err == nil {
+			match := nameMatcher(name, options)
 			for _, entry := range entries {
 				entryName := entry.Name()
-				if matched, err := matchesGlob(entryName, name); err == nil && matched {
+				if match(entryName) {
 					target := filepath.Join(dir, entryName)
-					if matches, err := pathMatches(target, options); err == nil && matches {
-						*results = append(*results, target)
-
-						// Check if we've reached the limit
-						if options.Limit > 0 && len(*results) >= options.Limit {
-							return nil
-						}
+					if ok, err := pathMatches(target, options); err == nil && ok {
+						matches = append(matches, EntryMatch{Path: target, Entry: entry})
 					}
 				}
 			}
@@ -422,47 +2144,151 @@ func findDownMultipleInDir(dir, name string, options *Options, currentDepth int,
 	} else {
 		// Handle exact filename match
 		target := filepath.Join(dir, name)
-		if matches, err := pathMatches(target, options); err == nil && matches {
-			*results = append(*results, target)
-
-			// Check if we've reached the limit
-			if options.Limit > 0 && len(*results) >= options.Limit {
-				return nil
+		if ok, err := pathMatches(target, options); err == nil && ok {
+			if info, err := lstat(options, target); err == nil {
+				matches = append(matches, EntryMatch{Path: target, Entry: fs.FileInfoToDirEntry(info)})
 			}
 		}
 	}
 
-	// Read directory contents
-	entries, err := os.ReadDir(dir)
+	return matches
+}
+
+// logDebug emits a debug record about path at depth to the configured logger
+func logDebug(options *Options, msg string, path string, depth int) {
+	if options.Logger == nil {
+		return
+	}
+	options.Logger.Debug("findup: "+msg, slog.String("path", path), slog.Int("depth", depth))
+}
+
+// reportPrune reports a directory that is not descended into to OnPrune and the configured logger
+func reportPrune(options *Options, dir string, depth int, reason string) {
+	if options.Stats != nil {
+		atomic.AddInt64(&options.Stats.Pruned, 1)
+	}
+	if options.OnPrune != nil {
+		options.OnPrune(dir, reason)
+	}
+	if options.Logger == nil {
+		return
+	}
+	options.Logger.Debug("findup: pruned directory", slog.String("path", dir), slog.Int("depth", depth), slog.String("reason", reason))
+}
+
+// selfMatch checks whether dir itself matches name
+func selfMatch(dir, name string, options *Options) (EntryMatch, bool) {
+	name = targetName(filepath.Dir(dir), name, options)
+	base := filepath.Base(dir)
+	if options.NameMatch != nil || options.NormalizeUnicode || IsGlob(name) {
+		if !nameMatcher(name, options)(base) {
+			return EntryMatch{}, false
+		}
+	} else if base != name {
+		return EntryMatch{}, false
+	}
+
+	if matches, err := pathMatches(dir, options); err != nil || !matches {
+		return EntryMatch{}, false
+	}
+
+	info, err := lstat(options, dir)
 	if err != nil {
-		return err
+		return EntryMatch{}, false
+	}
+	return EntryMatch{Path: dir, Entry: fs.FileInfoToDirEntry(info)}, true
+}
+
+// collectSubdirs returns the subdirectories of dir at depth that should be descended into
+func collectSubdirs(dir string, entries []fs.DirEntry, options *Options, depth int) []string {
+	dirDevice, hasDevice := uint64(0), false
+	if options.StayOnDevice {
+		dirDevice, hasDevice = deviceID(options, dir)
 	}
 
-	// Collect subdirectories
 	var subdirs []string
 	for _, entry := range entries {
-		if entry.IsDir() {
-			subdirs = append(subdirs, filepath.Join(dir, entry.Name()))
+		if !entry.IsDir() {
+			continue
 		}
-	}
 
-	// Search subdirectories
-	for _, subdir := range subdirs {
-		if err := findDownMultipleInDir(subdir, name, options, currentDepth+1, results); err != nil {
-			return err
+		subdir := filepath.Join(dir, entry.Name())
+		if matchesAny(entry.Name(), options.Exclude) {
+			reportPrune(options, subdir, depth, "excluded")
+			continue
 		}
-
-		// Check if we've reached the limit
-		if options.Limit > 0 && len(*results) >= options.Limit {
-			return nil
+		if options.gitignore != nil && options.gitignore.ignoredDir(subdir) {
+			reportPrune(options, subdir, depth, "gitignored")
+			continue
+		}
+		if hasDevice {
+			// Skip subdirectories mounted from another device
+			if subdirDevice, ok := deviceID(options, subdir); ok && subdirDevice != dirDevice {
+				reportPrune(options, subdir, depth, "mount point")
+				continue
+			}
 		}
+		subdirs = append(subdirs, subdir)
 	}
 
-	return nil
+	sortSubdirs(subdirs, options)
+	return subdirs
+}
+
+// sortSubdirs sorts subdirs, which are listed in ascending name order, into options.TraversalOrder
+func sortSubdirs(subdirs []string, options *Options) {
+	order := options.TraversalOrder
+	switch order {
+	case NameDesc:
+		sort.Sort(sort.Reverse(sort.StringSlice(subdirs)))
+	case MTimeDesc, MTimeAsc:
+		modTimes := make(map[string]time.Time, len(subdirs))
+		for _, subdir := range subdirs {
+			if info, err := stat(options, subdir); err == nil {
+				modTimes[subdir] = info.ModTime()
+			}
+		}
+		sort.SliceStable(subdirs, func(i, j int) bool {
+			if order == MTimeDesc {
+				return modTimes[subdirs[i]].After(modTimes[subdirs[j]])
+			}
+			return modTimes[subdirs[i]].Before(modTimes[subdirs[j]])
+		})
+	}
 }
 
 func pathMatches(path string, options *Options) (bool, error) {
-	info, err := os.Stat(path)
+	// Check the excluded names
+	if matchesAny(filepath.Base(path), options.ExcludeNames) {
+		return false, nil
+	}
+
+	// Check the patterns read from PatternsFromFile
+	if options.includePatterns != nil && !matchesAny(filepath.Base(path), options.includePatterns) {
+		return false, nil
+	}
+
+	// Check the extension
+	if options.ExtGlob != "" {
+		if matched, err := matchesGlob(filepath.Ext(path), options.ExtGlob); err != nil || !matched {
+			return false, err
+		}
+	}
+
+	// Check the path stays inside the base
+	if options.Base != "" && !insideBase(path, options) {
+		return false, nil
+	}
+
+	// Check the git tracked paths
+	if options.gitTracked != nil {
+		if _, ok := options.gitTracked[path]; !ok {
+			return false, nil
+		}
+	}
+
+	// Use Lstat so symbolic links are detected before being followed
+	info, err := lstat(options, path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
@@ -470,8 +2296,27 @@ func pathMatches(path string, options *Options) (bool, error) {
 		return false, err
 	}
 
+	// Check the .gitignore files, where symbolic links count as files
+	if options.gitignore != nil && options.gitignore.ignored(path, info.IsDir()) {
+		return false, nil
+	}
+
+	// Check the target of the symlink as written, without following it
+	if options.TargetGlob != "" {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return false, nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return false, err
+		}
+		if matched, err := matchesGlob(target, options.TargetGlob); err != nil || !matched {
+			return false, err
+		}
+	}
+
 	// Check if it's a symlink
-	if info.Mode()&os.ModeSymlink != 0 {
+	if info.Mode()&os.ModeSymlink != 0 && options.Type != SymlinkType && options.TargetGlob == "" {
 		if !options.AllowSymlinks {
 			return false, nil
 		}
@@ -488,22 +2333,105 @@ func pathMatches(path string, options *Options) (bool, error) {
 		}
 
 		// Check the resolved path
-		resolvedInfo, err := os.Stat(resolved)
+		resolvedInfo, err := stat(options, resolved)
 		if err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
 			return false, err
 		}
 		info = resolvedInfo
 	}
 
 	// Check the type
+	var typeMatches bool
 	switch options.Type {
 	case FileType:
-		return !info.IsDir(), nil
+		typeMatches = !info.IsDir()
 	case DirectoryType:
-		return info.IsDir(), nil
+		typeMatches = info.IsDir()
 	case BothType:
-		return true, nil
+		typeMatches = true
+	case SymlinkType:
+		typeMatches = info.Mode()&os.ModeSymlink != 0
 	default:
 		return false, fmt.Errorf("invalid path type: %v", options.Type)
 	}
+	if !typeMatches {
+		return false, nil
+	}
+
+	// Check the modification time
+	if !options.ModifiedAfter.IsZero() && !info.ModTime().After(options.ModifiedAfter) {
+		return false, nil
+	}
+	if !options.ModifiedBefore.IsZero() && !info.ModTime().Before(options.ModifiedBefore) {
+		return false, nil
+	}
+	if !options.newerThan.IsZero() && !info.ModTime().After(options.newerThan) {
+		return false, nil
+	}
+
+	// Check the ownership
+	if (options.Uid != nil || options.Gid != nil) && !ownerMatches(info, options.Uid, options.Gid) {
+		return false, nil
+	}
+
+	// Check the extended attribute
+	if options.XattrName != "" {
+		if matches, err := xattrMatches(path, options.XattrName, options.XattrValue); err != nil || !matches {
+			return false, err
+		}
+	}
+
+	// Check the leading content
+	if len(options.ContentPrefix) > 0 && options.Type == FileType {
+		if matches, err := hasContentPrefix(path, options.ContentPrefix); err != nil || !matches {
+			return false, err
+		}
+	}
+
+	// Check the content
+	if options.ContentRegexp != nil && options.Type == FileType {
+		return contentMatches(path, options.ContentRegexp, options.MaxContentScanBytes)
+	}
+
+	return true, nil
+}
+
+// hasContentPrefix reports whether the file at path starts with prefix, reading only len(prefix) bytes
+func hasContentPrefix(path string, prefix []byte) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, len(prefix))
+	if _, err := io.ReadFull(file, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// The file is shorter than the prefix
+			return false, nil
+		}
+		return false, err
+	}
+
+	return bytes.Equal(buf, prefix), nil
+}
+
+// contentMatches reports whether re matches the content of the file at path, streaming
+// at most maxBytes bytes of it (0 or less streams the whole file)
+func contentMatches(path string, re *regexp.Regexp, maxBytes int64) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if maxBytes > 0 {
+		reader = io.LimitReader(file, maxBytes)
+	}
+
+	return re.MatchReader(bufio.NewReader(reader)), nil
 }
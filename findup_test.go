@@ -1,9 +1,21 @@
 package findup
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestFindUp(t *testing.T) {
@@ -354,7 +366,7 @@ func TestFindDown(t *testing.T) {
 
 	t.Run("FindDown from root directory", func(t *testing.T) {
 		// Test finding file3.txt from tempDir
-		options := &Options{Cwd: tempDir}
+		options := &Options{Cwd: tempDir, Depth: -1}
 		result, err := FindDown("file3.txt", options)
 		if err != nil {
 			t.Fatalf("FindDown failed: %v", err)
@@ -443,7 +455,7 @@ func TestFindDownMultiple(t *testing.T) {
 
 	t.Run("FindDownMultiple from root directory", func(t *testing.T) {
 		// Test finding multiple file1.txt files
-		options := &Options{Cwd: tempDir}
+		options := &Options{Cwd: tempDir, Depth: -1}
 		results, err := FindDownMultiple("file1.txt", options)
 		if err != nil {
 			t.Fatalf("FindDownMultiple failed: %v", err)
@@ -455,7 +467,7 @@ func TestFindDownMultiple(t *testing.T) {
 
 	t.Run("FindDownMultiple with limit", func(t *testing.T) {
 		// Test with limit option
-		options := &Options{Cwd: tempDir, Limit: 2}
+		options := &Options{Cwd: tempDir, Depth: -1, Limit: 2}
 		results, err := FindDownMultiple("file1.txt", options)
 		if err != nil {
 			t.Fatalf("FindDownMultiple failed: %v", err)
@@ -486,6 +498,81 @@ func TestDefaultOptions(t *testing.T) {
 	if options.Strategy != BreadthFirst {
 		t.Errorf("Expected Strategy to be BreadthFirst, got %v", options.Strategy)
 	}
+	if options.StayOnDevice {
+		t.Error("Expected StayOnDevice to be false")
+	}
+}
+
+func TestSetDefaultOptions(t *testing.T) {
+	// tempDir/
+	//   ├── config.json
+	//   └── dir1/
+	//       └── dir2/
+	tempDir := createTestTree(t, "config.json", "dir1/dir2/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2")
+	t.Cleanup(func() { SetDefaultOptions(nil) })
+
+	t.Run("SetDefaultOptions applies to nil options", func(t *testing.T) {
+		defaults := DefaultOptions()
+		defaults.Cwd = cwd
+		defaults.StopAt = filepath.Dir(tempDir)
+		defaults.Type = DirectoryType
+		SetDefaultOptions(defaults)
+
+		result, err := FindUp("dir1", nil)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "dir1")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+
+		result, err = FindUp("config.json", nil)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected file to be rejected, got %s", result)
+		}
+	})
+
+	t.Run("SetDefaultOptions copies the options", func(t *testing.T) {
+		defaults := &Options{Cwd: cwd, Type: FileType}
+		SetDefaultOptions(defaults)
+		defaults.Type = DirectoryType
+
+		result, err := FindUp("config.json", nil)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("SetDefaultOptions with concurrent searches", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				SetDefaultOptions(&Options{Cwd: cwd})
+				if _, err := FindUp("config.json", nil); err != nil {
+					t.Errorf("FindUp failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("SetDefaultOptions with nil restores DefaultOptions", func(t *testing.T) {
+		SetDefaultOptions(nil)
+		if opts := packageDefaults(); !reflect.DeepEqual(opts, DefaultOptions()) {
+			t.Errorf("Expected DefaultOptions, got %+v", opts)
+		}
+	})
 }
 
 func TestPathType(t *testing.T) {
@@ -498,6 +585,9 @@ func TestPathType(t *testing.T) {
 	if BothType != 2 {
 		t.Error("Expected BothType to be 2")
 	}
+	if SymlinkType != 3 {
+		t.Error("Expected SymlinkType to be 3")
+	}
 }
 
 func TestSearchStrategy(t *testing.T) {
@@ -508,3 +598,3363 @@ func TestSearchStrategy(t *testing.T) {
 		t.Error("Expected DepthFirst to be 1")
 	}
 }
+
+// createTestTree creates a temporary directory containing the given paths.
+// Paths ending in a slash are created as directories, all others as files.
+func createTestTree(t testing.TB, paths ...string) string {
+	t.Helper()
+
+	tempDir, err := os.MkdirTemp("", "findup_tree_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	for _, p := range paths {
+		full := filepath.Join(tempDir, filepath.FromSlash(p))
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatalf("Failed to create dir %s: %v", full, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create dir %s: %v", filepath.Dir(full), err)
+		}
+		if err := os.WriteFile(full, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", full, err)
+		}
+	}
+
+	return tempDir
+}
+
+func TestFindUpSiblings(t *testing.T) {
+	// tempDir/
+	//   ├── go.mod
+	//   ├── main.go
+	//   ├── util.go
+	//   ├── README.md
+	//   └── pkg/
+	//       ├── lib.go
+	//       └── inner/
+	tempDir := createTestTree(t,
+		"go.mod",
+		"main.go",
+		"util.go",
+		"README.md",
+		"pkg/lib.go",
+		"pkg/inner/",
+	)
+	inner := filepath.Join(tempDir, "pkg", "inner")
+
+	t.Run("FindUpSiblings lists matching siblings of the marker", func(t *testing.T) {
+		results, err := FindUpSiblings("go.mod", "*.go", &Options{Cwd: inner})
+		if err != nil {
+			t.Fatalf("FindUpSiblings failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "main.go"),
+			filepath.Join(tempDir, "util.go"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindUpSiblings without marker", func(t *testing.T) {
+		results, err := FindUpSiblings("nonexistent.mod", "*.go", &Options{Cwd: inner, StopAt: tempDir})
+		if err != nil {
+			t.Fatalf("FindUpSiblings failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results, got %v", results)
+		}
+	})
+}
+
+func TestFindUpWithMatcherCache(t *testing.T) {
+	// tempDir/
+	//   ├── marker.txt
+	//   └── parent/
+	//       ├── a/
+	//       ├── b/
+	//       └── c/
+	tempDir := createTestTree(t,
+		"marker.txt",
+		"parent/a/",
+		"parent/b/",
+		"parent/c/",
+	)
+
+	calls := make(map[string]int)
+	matcher := func(directory string) (string, bool, error) {
+		calls[directory]++
+		if _, err := os.Stat(filepath.Join(directory, "marker.txt")); err == nil {
+			return directory, true, nil
+		}
+		return "", false, nil
+	}
+
+	cache := NewMatcherCache()
+	for _, name := range []string{"a", "b", "c"} {
+		options := &Options{Cwd: filepath.Join(tempDir, "parent", name), MatcherCache: cache}
+		result, err := FindUpWithMatcher(matcher, options)
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != tempDir {
+			t.Errorf("Expected %s, got %s", tempDir, result)
+		}
+	}
+
+	if len(calls) != 5 {
+		t.Errorf("Expected 5 evaluated directories, got %d: %v", len(calls), calls)
+	}
+	for directory, count := range calls {
+		if count != 1 {
+			t.Errorf("Expected %s to be evaluated once, got %d", directory, count)
+		}
+	}
+}
+
+func TestFindDownMultipleEntries(t *testing.T) {
+	// tempDir/
+	//   ├── file1.txt
+	//   ├── notes.md
+	//   └── dir1/
+	//       ├── file2.txt
+	//       └── dir2/
+	//           └── file1.txt
+	tempDir := createTestTree(t,
+		"file1.txt",
+		"notes.md",
+		"dir1/file2.txt",
+		"dir1/dir2/file1.txt",
+	)
+
+	for _, name := range []string{"*.txt", "file1.txt"} {
+		t.Run("FindDownMultipleEntries "+name, func(t *testing.T) {
+			results, err := FindDownMultipleEntries(name, &Options{Cwd: tempDir, Depth: -1})
+			if err != nil {
+				t.Fatalf("FindDownMultipleEntries failed: %v", err)
+			}
+			if len(results) == 0 {
+				t.Fatal("Expected results, got none")
+			}
+			for _, result := range results {
+				if result.Entry == nil {
+					t.Fatalf("Expected entry for %s", result.Path)
+				}
+				if result.Entry.Name() != filepath.Base(result.Path) {
+					t.Errorf("Expected entry name %s, got %s", filepath.Base(result.Path), result.Entry.Name())
+				}
+				if result.Entry.IsDir() {
+					t.Errorf("Expected %s to be a file entry", result.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestFindUpAny(t *testing.T) {
+	// tempDir/
+	//   ├── yarn.lock
+	//   └── project/
+	//       ├── package-lock.json
+	//       ├── pnpm-lock.yaml
+	//       └── src/
+	tempDir := createTestTree(t,
+		"yarn.lock",
+		"project/package-lock.json",
+		"project/pnpm-lock.yaml",
+		"project/src/",
+	)
+	src := filepath.Join(tempDir, "project", "src")
+
+	t.Run("FindUpAny prefers earlier names in the nearest directory", func(t *testing.T) {
+		names := []string{"yarn.lock", "pnpm-lock.yaml", "package-lock.json"}
+		match, err := FindUpAny(names, &Options{Cwd: src})
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "project", "pnpm-lock.yaml")
+		if match.Path != expected {
+			t.Errorf("Expected %s, got %s", expected, match.Path)
+		}
+		if match.MatchedName != "pnpm-lock.yaml" {
+			t.Errorf("Expected matched name pnpm-lock.yaml, got %s", match.MatchedName)
+		}
+	})
+
+	t.Run("FindUpAny with DepthFromStopAt", func(t *testing.T) {
+		match, err := FindUpAny([]string{"package-lock.json"}, &Options{Cwd: src, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		if match.DepthFromStopAt != 2 {
+			t.Errorf("Expected depth 2 below StopAt, got %d", match.DepthFromStopAt)
+		}
+
+		match, err = FindUpAny([]string{"yarn.lock"}, &Options{Cwd: src})
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		if match.DepthFromStopAt != 0 {
+			t.Errorf("Expected depth 0 without StopAt, got %d", match.DepthFromStopAt)
+		}
+	})
+
+	t.Run("FindUpAny follows the given priority order", func(t *testing.T) {
+		names := []string{"package-lock.json", "pnpm-lock.yaml"}
+		match, err := FindUpAny(names, &Options{Cwd: src})
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		if match.MatchedName != "package-lock.json" {
+			t.Errorf("Expected matched name package-lock.json, got %s", match.MatchedName)
+		}
+	})
+
+	t.Run("FindUpAny without match", func(t *testing.T) {
+		match, err := FindUpAny([]string{"bun.lockb"}, &Options{Cwd: src, StopAt: tempDir})
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		if match.Path != "" || match.MatchedName != "" {
+			t.Errorf("Expected empty match, got %+v", match)
+		}
+	})
+}
+
+func TestExcludeNames(t *testing.T) {
+	// tempDir/
+	//   ├── main_test.go
+	//   ├── main.go
+	//   └── pkg/
+	//       ├── lib_test.go
+	//       └── sub/
+	tempDir := createTestTree(t,
+		"main_test.go",
+		"main.go",
+		"pkg/lib_test.go",
+		"pkg/sub/",
+	)
+
+	t.Run("FindUp excluding names", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "pkg", "sub"), ExcludeNames: []string{"*_test.go"}}
+		result, err := FindUp("*.go", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "main.go")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownMultiple excluding names", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, ExcludeNames: []string{"*_test.go"}}
+		results, err := FindDownMultiple("*.go", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "main.go")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestModifiedWithin(t *testing.T) {
+	// tempDir/
+	//   ├── old.log
+	//   ├── recent.log
+	//   └── dir1/
+	//       └── ancient.log
+	tempDir := createTestTree(t,
+		"old.log",
+		"recent.log",
+		"dir1/ancient.log",
+	)
+
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"old.log":          10 * 24 * time.Hour,
+		"recent.log":       2 * 24 * time.Hour,
+		"dir1/ancient.log": 30 * 24 * time.Hour,
+	}
+	for name, age := range ages {
+		modTime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(tempDir, name), modTime, modTime); err != nil {
+			t.Fatalf("Failed to age %s: %v", name, err)
+		}
+	}
+
+	t.Run("FindDownMultiple modified within a week", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, ModifiedWithin: 7 * 24 * time.Hour}
+		results, err := FindDownMultiple("*.log", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "recent.log")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownMultiple with absolute window taking precedence", func(t *testing.T) {
+		options := &Options{
+			Cwd:            tempDir,
+			Depth:          -1,
+			ModifiedWithin: 7 * 24 * time.Hour,
+			ModifiedAfter:  now.Add(-20 * 24 * time.Hour),
+			ModifiedBefore: now.Add(-5 * 24 * time.Hour),
+		}
+		results, err := FindDownMultiple("*.log", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "old.log")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindUp modified within a day", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "dir1"), StopAt: filepath.Dir(tempDir), ModifiedWithin: 24 * time.Hour}
+		result, err := FindUp("*.log", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestFindUpOutermost(t *testing.T) {
+	// tempDir/
+	//   └── workspace/
+	//       ├── go.work
+	//       └── nested/
+	//           ├── go.work
+	//           └── module/
+	tempDir := createTestTree(t,
+		"workspace/go.work",
+		"workspace/nested/go.work",
+		"workspace/nested/module/",
+	)
+	module := filepath.Join(tempDir, "workspace", "nested", "module")
+
+	t.Run("FindUpOutermost returns the highest match", func(t *testing.T) {
+		result, err := FindUpOutermost("go.work", &Options{Cwd: module, StopAt: tempDir, Limit: 1})
+		if err != nil {
+			t.Fatalf("FindUpOutermost failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "workspace", "go.work")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUpOutermost ignores StopAtFirstLevel", func(t *testing.T) {
+		result, err := FindUpOutermost("go.work", &Options{Cwd: module, StopAt: tempDir, StopAtFirstLevel: true})
+		if err != nil {
+			t.Fatalf("FindUpOutermost failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "workspace", "go.work")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUpOutermost honors StopAt", func(t *testing.T) {
+		stopAt := filepath.Join(tempDir, "workspace")
+		result, err := FindUpOutermost("go.work", &Options{Cwd: module, StopAt: stopAt})
+		if err != nil {
+			t.Fatalf("FindUpOutermost failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "workspace", "nested", "go.work")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestAllowSymlinks(t *testing.T) {
+	// tempDir/
+	//   ├── real.json
+	//   ├── config.json -> real.json
+	//   ├── dangling.json -> missing.json
+	//   └── dir1/
+	tempDir := createTestTree(t, "real.json", "dir1/")
+	cwd := filepath.Join(tempDir, "dir1")
+	if err := os.Symlink("real.json", filepath.Join(tempDir, "config.json")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("missing.json", filepath.Join(tempDir, "dangling.json")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	t.Run("FindUp excludes symlinks when AllowSymlinks is false", func(t *testing.T) {
+		result, err := FindUp("config.json", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), AllowSymlinks: false})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected symlink to be excluded, got %s", result)
+		}
+	})
+
+	t.Run("FindUp follows symlinks when AllowSymlinks is true", func(t *testing.T) {
+		result, err := FindUp("config.json", &Options{Cwd: cwd, AllowSymlinks: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUp skips dangling symlinks", func(t *testing.T) {
+		result, err := FindUp("dangling.json", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), AllowSymlinks: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected dangling symlink to be skipped, got %s", result)
+		}
+	})
+
+	t.Run("FindDownMultiple excludes symlinks when AllowSymlinks is false", func(t *testing.T) {
+		results, err := FindDownMultiple("*.json", &Options{Cwd: tempDir, Depth: -1, AllowSymlinks: false})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "real.json")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestResolveCwd(t *testing.T) {
+	// tempDir/
+	//   ├── marker.txt
+	//   ├── real/
+	//   │   └── sub/
+	//   └── link -> real
+	tempDir := createTestTree(t,
+		"marker.txt",
+		"real/sub/",
+	)
+	realDir := filepath.Join(tempDir, "real")
+	if err := os.Symlink(realDir, filepath.Join(tempDir, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	cwd := filepath.Join(tempDir, "link", "sub")
+
+	t.Run("FindUp with unresolved Cwd passes StopAt", func(t *testing.T) {
+		result, err := FindUp("marker.txt", &Options{Cwd: cwd, StopAt: realDir})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "marker.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUp with resolved Cwd honors StopAt", func(t *testing.T) {
+		result, err := FindUp("marker.txt", &Options{Cwd: cwd, StopAt: realDir, ResolveCwd: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result (marker.txt is above stopAt), got %s", result)
+		}
+	})
+}
+
+func TestResolveCwdDarwinTmp(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("Requires the /tmp symlink on macOS")
+	}
+
+	tempDir, err := os.MkdirTemp("/tmp", "findup_resolve_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sub := filepath.Join(tempDir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("Failed to create sub: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "marker.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create marker: %v", err)
+	}
+
+	stopAt, err := filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve %s: %v", tempDir, err)
+	}
+
+	result, err := FindUp("marker.txt", &Options{Cwd: sub, StopAt: stopAt, ResolveCwd: true})
+	if err != nil {
+		t.Fatalf("FindUp failed: %v", err)
+	}
+	if result != "" {
+		t.Errorf("Expected empty result (marker.txt is at stopAt), got %s", result)
+	}
+}
+
+func TestContentPrefix(t *testing.T) {
+	// tempDir/
+	//   ├── build         (#!/bin/sh)
+	//   ├── notes         (plain text)
+	//   └── scripts/
+	//       ├── deploy    (#!/bin/bash)
+	//       └── sub/
+	//           └── s     (#!)
+	tempDir := createTestTree(t, "scripts/sub/")
+	contents := map[string]string{
+		"build":          "#!/bin/sh\necho build\n",
+		"notes":          "some notes\n",
+		"scripts/deploy": "#!/bin/bash\necho deploy\n",
+		"scripts/sub/s":  "#!",
+	}
+	for name, content := range contents {
+		if err := os.WriteFile(filepath.Join(tempDir, filepath.FromSlash(name)), []byte(content), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	t.Run("FindUp nearest shell script", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "scripts", "sub"), ContentPrefix: []byte("#!/bin/sh")}
+		result, err := FindUp("*", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "build")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownMultiple with shebang prefix", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, ContentPrefix: []byte("#!/bin/")}
+		results, err := FindDownMultiple("*", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "build"),
+			filepath.Join(tempDir, "scripts", "deploy"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestFilesystemRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	root := filepath.VolumeName(wd) + string(filepath.Separator)
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("Failed to read root: %v", err)
+	}
+	var rootDir string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			rootDir = entry.Name()
+			break
+		}
+	}
+	if rootDir == "" {
+		t.Skip("No directory found at the filesystem root")
+	}
+
+	t.Run("FindUp from root checks root itself", func(t *testing.T) {
+		result, err := FindUp(rootDir, &Options{Cwd: root, Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(root, rootDir)
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUp from root without match", func(t *testing.T) {
+		result, err := FindUp("findup-nonexistent-marker", &Options{Cwd: root})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindUpMultiple from root", func(t *testing.T) {
+		results, err := FindUpMultiple(rootDir, &Options{Cwd: root, Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result, got %v", results)
+		}
+	})
+
+	t.Run("FindDown from root respects the default depth", func(t *testing.T) {
+		options := DefaultOptions()
+		options.Cwd = root
+		result, err := FindDown("findup-nonexistent-marker", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindDownMultiple from root with zero options stays at root", func(t *testing.T) {
+		results, err := FindDownMultiple("*", &Options{Cwd: root, Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) == 0 {
+			t.Fatal("Expected the directories at the root")
+		}
+		for _, result := range results {
+			if filepath.Dir(result) != root {
+				t.Errorf("Expected only entries directly in %s, got %s", root, result)
+			}
+		}
+	})
+
+	t.Run("FindDownMultiple from root with depth 1", func(t *testing.T) {
+		results, err := FindDownMultiple(rootDir, &Options{Cwd: root, Depth: 1, Type: DirectoryType, Limit: 1})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(root, rootDir)}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestSeen(t *testing.T) {
+	// tempDir/
+	//   ├── a.log
+	//   └── dir1/
+	//       └── b.log
+	tempDir := createTestTree(t,
+		"a.log",
+		"dir1/b.log",
+	)
+	seen := make(map[string]struct{})
+	options := &Options{Cwd: tempDir, Depth: -1, Seen: seen}
+
+	first, err := FindDownMultiple("*.log", options)
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 results in the first scan, got %v", first)
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected 2 seen paths, got %v", seen)
+	}
+
+	// Create a new file between scans
+	newFile := filepath.Join(tempDir, "dir1", "c.log")
+	if err := os.WriteFile(newFile, []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create file %s: %v", newFile, err)
+	}
+
+	second, err := FindDownMultiple("*.log", options)
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	expected := []string{newFile}
+	if !reflect.DeepEqual(second, expected) {
+		t.Errorf("Expected %v, got %v", expected, second)
+	}
+
+	third, err := FindDownMultiple("*.log", options)
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	if len(third) != 0 {
+		t.Errorf("Expected no new results, got %v", third)
+	}
+}
+
+func TestIncludeSelf(t *testing.T) {
+	// tempDir/
+	//   └── project/
+	//       └── packages/
+	//           └── project/
+	tempDir := createTestTree(t, "project/packages/project/")
+	project := filepath.Join(tempDir, "project")
+
+	t.Run("FindDown without IncludeSelf", func(t *testing.T) {
+		result, err := FindDown("project", &Options{Cwd: project, Depth: -1, Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(project, "packages", "project")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDown with IncludeSelf", func(t *testing.T) {
+		result, err := FindDown("project", &Options{Cwd: project, Depth: -1, Type: DirectoryType, IncludeSelf: true})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != project {
+			t.Errorf("Expected %s, got %s", project, result)
+		}
+	})
+
+	t.Run("FindDownMultiple with IncludeSelf", func(t *testing.T) {
+		results, err := FindDownMultiple("proj*", &Options{Cwd: project, Depth: -1, Type: DirectoryType, IncludeSelf: true})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{project, filepath.Join(project, "packages", "project")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDown with IncludeSelf and a file type", func(t *testing.T) {
+		result, err := FindDown("project", &Options{Cwd: project, Depth: -1, Type: FileType, IncludeSelf: true})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestLogger(t *testing.T) {
+	// tempDir/
+	//   └── dir1/
+	//       ├── target.txt
+	//       └── dir2/
+	//           └── dir3/
+	tempDir := createTestTree(t,
+		"dir1/target.txt",
+		"dir1/dir2/dir3/",
+	)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	options := &Options{Cwd: tempDir, Depth: 2, Logger: logger}
+	result, err := FindDownMultiple("target.txt", options)
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 result, got %v", result)
+	}
+
+	type record struct {
+		Msg    string `json:"msg"`
+		Path   string `json:"path"`
+		Depth  int    `json:"depth"`
+		Reason string `json:"reason"`
+	}
+	var records []record
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var r record
+		if err := decoder.Decode(&r); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+		records = append(records, r)
+	}
+
+	expected := []record{
+		{Msg: "findup: entering directory", Path: tempDir, Depth: 0},
+		{Msg: "findup: entering directory", Path: filepath.Join(tempDir, "dir1"), Depth: 1},
+		{Msg: "findup: match", Path: filepath.Join(tempDir, "dir1", "target.txt"), Depth: 1},
+		{Msg: "findup: entering directory", Path: filepath.Join(tempDir, "dir1", "dir2"), Depth: 2},
+		{Msg: "findup: pruned directory", Path: filepath.Join(tempDir, "dir1", "dir2", "dir3"), Depth: 3, Reason: "depth"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected records %+v, got %+v", expected, records)
+	}
+}
+
+func TestFindUpByStem(t *testing.T) {
+	// tempDir/
+	//   ├── config.toml
+	//   └── app/
+	//       ├── config.json
+	//       ├── config.yaml
+	//       ├── configuration.json
+	//       └── src/
+	tempDir := createTestTree(t,
+		"config.toml",
+		"app/config.json",
+		"app/config.yaml",
+		"app/configuration.json",
+		"app/src/",
+	)
+	src := filepath.Join(tempDir, "app", "src")
+
+	t.Run("FindUpByStem returns the nearest variant", func(t *testing.T) {
+		result, err := FindUpByStem("config", &Options{Cwd: src})
+		if err != nil {
+			t.Fatalf("FindUpByStem failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "app", "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUpByStemMultiple returns every variant", func(t *testing.T) {
+		results, err := FindUpByStemMultiple("config", &Options{Cwd: src, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpByStemMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "app", "config.json"),
+			filepath.Join(tempDir, "app", "config.yaml"),
+			filepath.Join(tempDir, "config.toml"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindUpByStem honors Type", func(t *testing.T) {
+		result, err := FindUpByStem("config", &Options{Cwd: src, Type: DirectoryType, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpByStem failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestTraversalOrder(t *testing.T) {
+	// tempDir/
+	//   ├── a-branch/
+	//   │   └── target.txt (older)
+	//   └── b-branch/
+	//       └── target.txt (newer)
+	tempDir := createTestTree(t,
+		"a-branch/target.txt",
+		"b-branch/target.txt",
+	)
+
+	now := time.Now()
+	olderTime := now.Add(-48 * time.Hour)
+	newerTime := now.Add(-24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(tempDir, "a-branch"), olderTime, olderTime); err != nil {
+		t.Fatalf("Failed to age a-branch: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(tempDir, "b-branch"), newerTime, newerTime); err != nil {
+		t.Fatalf("Failed to age b-branch: %v", err)
+	}
+	older := filepath.Join(tempDir, "a-branch", "target.txt")
+	newer := filepath.Join(tempDir, "b-branch", "target.txt")
+
+	tests := []struct {
+		order    TraversalOrder
+		expected string
+	}{
+		{NameAsc, older},
+		{NameDesc, newer},
+		{MTimeDesc, newer},
+		{MTimeAsc, older},
+	}
+
+	for _, tt := range tests {
+		result, err := FindDown("target.txt", &Options{Cwd: tempDir, Depth: -1, Strategy: DepthFirst, TraversalOrder: tt.order})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != tt.expected {
+			t.Errorf("Order %v: expected %s, got %s", tt.order, tt.expected, result)
+		}
+	}
+
+	results, err := FindDownMultiple("target.txt", &Options{Cwd: tempDir, Depth: -1, TraversalOrder: MTimeDesc})
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	expected := []string{newer, older}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %v, got %v", expected, results)
+	}
+}
+
+func TestIsGlob(t *testing.T) {
+	tests := map[string]bool{
+		"go.mod":      false,
+		"my file.txt": false,
+		"*.go":        true,
+		"file?.txt":   true,
+		"file[12].md": true,
+	}
+	for name, expected := range tests {
+		if IsGlob(name) != expected {
+			t.Errorf("IsGlob(%q): expected %v", name, expected)
+		}
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	t.Run("EscapeGlob leaves literal names unchanged", func(t *testing.T) {
+		for _, name := range []string{"go.mod", "my file.txt", "a-b_c"} {
+			if escaped := EscapeGlob(name); escaped != name {
+				t.Errorf("EscapeGlob(%q): expected unchanged, got %q", name, escaped)
+			}
+		}
+	})
+
+	t.Run("EscapeGlob patterns match only the literal name", func(t *testing.T) {
+		for _, name := range []string{"file[1].txt", "what?.md", "a*b", "[x]*?"} {
+			escaped := EscapeGlob(name)
+			if matched, err := filepath.Match(escaped, name); err != nil || !matched {
+				t.Errorf("EscapeGlob(%q) = %q does not match itself: %v", name, escaped, err)
+			}
+		}
+		if matched, _ := filepath.Match(EscapeGlob("file[1].txt"), "file1.txt"); matched {
+			t.Error("Expected escaped pattern not to match file1.txt")
+		}
+		if matched, _ := filepath.Match(EscapeGlob("a*b"), "axxb"); matched {
+			t.Error("Expected escaped pattern not to match axxb")
+		}
+	})
+
+	t.Run("FindUp with an escaped name", func(t *testing.T) {
+		// tempDir/
+		//   ├── file1.txt
+		//   ├── file[1].txt
+		//   └── dir1/
+		tempDir := createTestTree(t,
+			"file1.txt",
+			"file[1].txt",
+			"dir1/",
+		)
+		result, err := FindUp(EscapeGlob("file[1].txt"), &Options{Cwd: filepath.Join(tempDir, "dir1")})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "file[1].txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestFindDownWithDepthMatcher(t *testing.T) {
+	// tempDir/
+	//   ├── Makefile
+	//   ├── a/
+	//   │   ├── Makefile
+	//   │   └── b/
+	//   │       └── Makefile
+	//   └── c/
+	tempDir := createTestTree(t,
+		"Makefile",
+		"a/Makefile",
+		"a/b/Makefile",
+		"c/",
+	)
+
+	var visited []int
+	matcher := func(directory string, depth int) (string, bool, error) {
+		visited = append(visited, depth)
+		if depth < 2 {
+			return "", false, nil
+		}
+		if _, err := os.Stat(filepath.Join(directory, "Makefile")); err == nil {
+			return directory, true, nil
+		}
+		return "", false, nil
+	}
+
+	t.Run("FindDownWithDepthMatcher skips shallow matches", func(t *testing.T) {
+		result, err := FindDownWithDepthMatcher(matcher, &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownWithDepthMatcher failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "a", "b")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+		if !reflect.DeepEqual(visited, []int{0, 1, 2}) {
+			t.Errorf("Expected depths [0 1 2], got %v", visited)
+		}
+	})
+
+	t.Run("FindDownWithDepthMatcher with depth limit", func(t *testing.T) {
+		result, err := FindDownWithDepthMatcher(matcher, &Options{Cwd: tempDir, Depth: 1})
+		if err != nil {
+			t.Fatalf("FindDownWithDepthMatcher failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestAbsoluteCwd(t *testing.T) {
+	tempDir := createTestTree(t, "marker.txt", "dir1/dir2/")
+
+	result, err := FindUp("marker.txt", &Options{Cwd: filepath.Join(tempDir, "dir1", "dir2"), AbsoluteCwd: true})
+	if err != nil {
+		t.Fatalf("FindUp failed: %v", err)
+	}
+	expected := filepath.Join(tempDir, "marker.txt")
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func BenchmarkAbsoluteCwd(b *testing.B) {
+	tempDir := createTestTree(b, "marker.txt", "dir1/dir2/dir3/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2", "dir3")
+
+	for _, absolute := range []bool{false, true} {
+		name := "Abs"
+		if absolute {
+			name = "AbsoluteCwd"
+		}
+		b.Run(name, func(b *testing.B) {
+			options := &Options{Cwd: cwd, AbsoluteCwd: absolute}
+			for i := 0; i < b.N; i++ {
+				if _, err := FindUp("marker.txt", options); err != nil {
+					b.Fatalf("FindUp failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPerDirLimit(t *testing.T) {
+	// tempDir/
+	//   ├── 1.jpg ... 5.jpg
+	//   └── dir1/
+	//       └── 6.jpg
+	tempDir := createTestTree(t,
+		"1.jpg",
+		"2.jpg",
+		"3.jpg",
+		"4.jpg",
+		"5.jpg",
+		"dir1/6.jpg",
+	)
+
+	results, err := FindDownMultiple("*.jpg", &Options{Cwd: tempDir, Depth: -1, PerDirLimit: 2})
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	expected := []string{
+		filepath.Join(tempDir, "1.jpg"),
+		filepath.Join(tempDir, "2.jpg"),
+		filepath.Join(tempDir, "dir1", "6.jpg"),
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %v, got %v", expected, results)
+	}
+
+	results, err = FindDownMultiple("*.jpg", &Options{Cwd: tempDir, Depth: -1, PerDirLimit: 2, Limit: 1})
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result due to limit, got %v", results)
+	}
+}
+
+func TestFindUpRegexpCapture(t *testing.T) {
+	// tempDir/
+	//   ├── config.dev.json
+	//   └── app/
+	//       ├── config.prod.json
+	//       └── src/
+	tempDir := createTestTree(t,
+		"config.dev.json",
+		"app/config.prod.json",
+		"app/src/",
+	)
+	re := regexp.MustCompile(`^config\.(?P<env>\w+)\.(?P<ext>json|yaml)$`)
+
+	t.Run("FindUpRegexpCapture returns captured groups", func(t *testing.T) {
+		result, captures, err := FindUpRegexpCapture(re, &Options{Cwd: filepath.Join(tempDir, "app", "src")})
+		if err != nil {
+			t.Fatalf("FindUpRegexpCapture failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "app", "config.prod.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+		expectedCaptures := map[string]string{"env": "prod", "ext": "json"}
+		if !reflect.DeepEqual(captures, expectedCaptures) {
+			t.Errorf("Expected captures %v, got %v", expectedCaptures, captures)
+		}
+	})
+
+	t.Run("FindUpRegexpCapture without match", func(t *testing.T) {
+		re := regexp.MustCompile(`^settings\.(?P<env>\w+)\.json$`)
+		result, captures, err := FindUpRegexpCapture(re, &Options{Cwd: tempDir, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpRegexpCapture failed: %v", err)
+		}
+		if result != "" || captures != nil {
+			t.Errorf("Expected empty result, got %s and %v", result, captures)
+		}
+	})
+}
+
+func TestPreferRealFiles(t *testing.T) {
+	// tempDir/
+	//   ├── a.conf -> z.conf
+	//   ├── z.conf
+	//   └── dir1/
+	tempDir := createTestTree(t,
+		"z.conf",
+		"dir1/",
+	)
+	if err := os.Symlink("z.conf", filepath.Join(tempDir, "a.conf")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	cwd := filepath.Join(tempDir, "dir1")
+
+	t.Run("FindUp picks the first candidate by default", func(t *testing.T) {
+		result, err := FindUp("*.conf", &Options{Cwd: cwd, AllowSymlinks: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "a.conf")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUp prefers the real file", func(t *testing.T) {
+		result, err := FindUp("*.conf", &Options{Cwd: cwd, AllowSymlinks: true, PreferRealFiles: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "z.conf")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDown prefers the real file", func(t *testing.T) {
+		result, err := FindDown("*.conf", &Options{Cwd: tempDir, AllowSymlinks: true, PreferRealFiles: true})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "z.conf")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestFindUpOr(t *testing.T) {
+	tempDir := createTestTree(t, "config.json", "dir1/")
+	cwd := filepath.Join(tempDir, "dir1")
+
+	t.Run("FindUpOr with match", func(t *testing.T) {
+		result, err := FindUpOr("config.json", "/etc/default.json", &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpOr failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUpOr without match", func(t *testing.T) {
+		result, err := FindUpOr("missing.json", "/etc/default.json", &Options{Cwd: cwd, StopAt: tempDir})
+		if err != nil {
+			t.Fatalf("FindUpOr failed: %v", err)
+		}
+		if result != "/etc/default.json" {
+			t.Errorf("Expected fallback, got %s", result)
+		}
+	})
+
+	t.Run("FindUpOrFunc only calls fallback without match", func(t *testing.T) {
+		calls := 0
+		fallback := func() (string, error) {
+			calls++
+			return "computed", nil
+		}
+
+		result, err := FindUpOrFunc("config.json", fallback, &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpOrFunc failed: %v", err)
+		}
+		if result != filepath.Join(tempDir, "config.json") || calls != 0 {
+			t.Errorf("Expected match without fallback call, got %s after %d calls", result, calls)
+		}
+
+		result, err = FindUpOrFunc("missing.json", fallback, &Options{Cwd: cwd, StopAt: tempDir})
+		if err != nil {
+			t.Fatalf("FindUpOrFunc failed: %v", err)
+		}
+		if result != "computed" || calls != 1 {
+			t.Errorf("Expected fallback result after 1 call, got %s after %d calls", result, calls)
+		}
+	})
+}
+
+func TestFindDownNearestLevel(t *testing.T) {
+	// tempDir/
+	//   ├── a/
+	//   │   ├── notes.md
+	//   │   └── deep/
+	//   │       └── deep.md
+	//   ├── b/
+	//   │   └── readme.md
+	//   └── c/
+	//       └── main.go
+	tempDir := createTestTree(t,
+		"a/notes.md",
+		"a/deep/deep.md",
+		"b/readme.md",
+		"c/main.go",
+	)
+
+	t.Run("FindDownNearestLevel returns every match at the shallowest level", func(t *testing.T) {
+		results, err := FindDownNearestLevel("*.md", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownNearestLevel failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "a", "notes.md"),
+			filepath.Join(tempDir, "b", "readme.md"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownNearestLevel with match only deeper", func(t *testing.T) {
+		results, err := FindDownNearestLevel("deep.md", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownNearestLevel failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "a", "deep", "deep.md")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownNearestLevel respects depth", func(t *testing.T) {
+		results, err := FindDownNearestLevel("deep.md", &Options{Cwd: tempDir, Depth: 1})
+		if err != nil {
+			t.Fatalf("FindDownNearestLevel failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no matches, got %v", results)
+		}
+	})
+}
+
+func TestAncestorDirs(t *testing.T) {
+	// tempDir/
+	//   └── dir1/
+	//       └── dir2/
+	tempDir := createTestTree(t, "dir1/dir2/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2")
+
+	t.Run("AncestorDirs in root-first order", func(t *testing.T) {
+		results, err := AncestorDirs(&Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("AncestorDirs failed: %v", err)
+		}
+		if len(results) < 3 {
+			t.Fatalf("Expected at least 3 directories, got %v", results)
+		}
+		if root := results[0]; filepath.Dir(root) != root {
+			t.Errorf("Expected the filesystem root first, got %s", root)
+		}
+		for i := 1; i < len(results); i++ {
+			if filepath.Dir(results[i]) != results[i-1] {
+				t.Errorf("Expected %s to be the parent of %s", results[i-1], results[i])
+			}
+		}
+		if last := results[len(results)-1]; last != cwd {
+			t.Errorf("Expected %s last, got %s", cwd, last)
+		}
+	})
+
+	t.Run("AncestorDirs with StopAt", func(t *testing.T) {
+		results, err := AncestorDirs(&Options{Cwd: cwd, StopAt: tempDir})
+		if err != nil {
+			t.Fatalf("AncestorDirs failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "dir1"), cwd}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestCollectErrors(t *testing.T) {
+	// tempDir/
+	//   ├── a/
+	//   │   └── file.txt
+	//   ├── locked/   (unreadable)
+	//   │   └── file.txt
+	//   └── z/
+	//       └── file.txt
+	tempDir := createTestTree(t, "a/file.txt", "locked/file.txt", "z/file.txt")
+	locked := filepath.Join(tempDir, "locked")
+
+	// Listing locked fails the way an unreadable directory does, whoever runs the tests
+	readDir := func(dir string) ([]fs.DirEntry, error) {
+		if dir == locked {
+			return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrPermission}
+		}
+		return os.ReadDir(dir)
+	}
+
+	t.Run("FindDownMultiple aborts without CollectErrors", func(t *testing.T) {
+		_, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, ReadDirFunc: readDir})
+		if err == nil {
+			t.Error("Expected error for unreadable directory")
+		}
+	})
+
+	t.Run("FindDownMultiple with CollectErrors", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, CollectErrors: true, ReadDirFunc: readDir})
+		expected := []string{
+			filepath.Join(tempDir, "a", "file.txt"),
+			filepath.Join(tempDir, "z", "file.txt"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) {
+			t.Fatalf("Expected a *fs.PathError, got %v", err)
+		}
+		if pathErr.Path != locked {
+			t.Errorf("Expected error for %s, got %s", locked, pathErr.Path)
+		}
+	})
+}
+
+func TestMaxResults(t *testing.T) {
+	// tempDir/
+	//   ├── a.txt
+	//   ├── b.txt
+	//   └── dir1/
+	//       └── c.txt
+	tempDir := createTestTree(t, "a.txt", "b.txt", "dir1/c.txt")
+
+	t.Run("FindDownMultiple exceeding MaxResults", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, MaxResults: 2})
+		if !errors.Is(err, ErrTooManyResults) {
+			t.Fatalf("Expected ErrTooManyResults, got %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "a.txt"), filepath.Join(tempDir, "b.txt")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownMultiple within MaxResults", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, MaxResults: 3})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 3 {
+			t.Errorf("Expected 3 results, got %v", results)
+		}
+	})
+
+	t.Run("Limit below MaxResults", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, Limit: 2, MaxResults: 2})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected 2 results, got %v", results)
+		}
+	})
+}
+
+func TestSymlinkType(t *testing.T) {
+	// tempDir/
+	//   ├── file.txt
+	//   ├── dir1/
+	//   ├── file-link -> file.txt
+	//   ├── dir-link -> dir1
+	//   └── dir2/
+	tempDir := createTestTree(t, "file.txt", "dir1/", "dir2/")
+	for link, target := range map[string]string{"file-link": "file.txt", "dir-link": "dir1"} {
+		if err := os.Symlink(target, filepath.Join(tempDir, link)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	t.Run("FindDownMultiple finds only symlinks", func(t *testing.T) {
+		results, err := FindDownMultiple("*", &Options{Cwd: tempDir, Depth: -1, Type: SymlinkType})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "dir-link"), filepath.Join(tempDir, "file-link")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("SymlinkType ignores AllowSymlinks", func(t *testing.T) {
+		result, err := FindUp("file-link", &Options{Cwd: filepath.Join(tempDir, "dir2"), Type: SymlinkType, AllowSymlinks: false})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "file-link")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("SymlinkType rejects regular files", func(t *testing.T) {
+		result, err := FindUp("file.txt", &Options{Cwd: filepath.Join(tempDir, "dir2"), StopAt: filepath.Dir(tempDir), Type: SymlinkType})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestStartDepth(t *testing.T) {
+	// tempDir/
+	//   └── a/
+	//       ├── shallow.txt
+	//       └── b/
+	//           └── deep.txt
+	tempDir := createTestTree(t, "a/shallow.txt", "a/b/deep.txt")
+
+	t.Run("FindDown without StartDepth", func(t *testing.T) {
+		result, err := FindDown("deep.txt", &Options{Cwd: tempDir, Depth: 2})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "a", "b", "deep.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDown with StartDepth shifts the cutoff", func(t *testing.T) {
+		result, err := FindDown("deep.txt", &Options{Cwd: tempDir, Depth: 2, StartDepth: 1})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindDownMultiple with StartDepth", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: 2, StartDepth: 1})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "a", "shallow.txt")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestIsWithin(t *testing.T) {
+	// tempDir/
+	//   └── project/
+	//       ├── .git/
+	//       └── src/
+	tempDir := createTestTree(t, "project/.git/", "project/src/")
+	project := filepath.Join(tempDir, "project")
+
+	t.Run("IsWithin inside the marker directory", func(t *testing.T) {
+		inside, root, err := IsWithin(".git", &Options{Cwd: filepath.Join(project, "src"), Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("IsWithin failed: %v", err)
+		}
+		if !inside {
+			t.Error("Expected to be inside the project")
+		}
+		if root != project {
+			t.Errorf("Expected %s, got %s", project, root)
+		}
+	})
+
+	t.Run("IsWithin outside the marker directory", func(t *testing.T) {
+		inside, root, err := IsWithin(".git", &Options{Cwd: tempDir, StopAt: filepath.Dir(tempDir), Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("IsWithin failed: %v", err)
+		}
+		if inside || root != "" {
+			t.Errorf("Expected to be outside any project, got %v and %s", inside, root)
+		}
+	})
+}
+
+func TestTrailingSeparator(t *testing.T) {
+	// tempDir/
+	//   ├── node_modules/
+	//   └── app/
+	//       └── src/
+	tempDir := createTestTree(t, "node_modules/", "app/src/")
+	nodeModules := filepath.Join(tempDir, "node_modules")
+	src := filepath.Join(tempDir, "app", "src")
+
+	for _, name := range []string{"node_modules/", "node_modules" + string(filepath.Separator), "node_*/"} {
+		t.Run("FindUp with "+name, func(t *testing.T) {
+			result, err := FindUp(name, &Options{Cwd: src, Type: DirectoryType})
+			if err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+			if result != nodeModules {
+				t.Errorf("Expected %s, got %s", nodeModules, result)
+			}
+		})
+
+		t.Run("FindDownMultiple with "+name, func(t *testing.T) {
+			results, err := FindDownMultiple(name, &Options{Cwd: tempDir, Depth: -1, Type: DirectoryType})
+			if err != nil {
+				t.Fatalf("FindDownMultiple failed: %v", err)
+			}
+			expected := []string{nodeModules}
+			if !reflect.DeepEqual(results, expected) {
+				t.Errorf("Expected %v, got %v", expected, results)
+			}
+		})
+	}
+
+	t.Run("FindDown with IncludeSelf", func(t *testing.T) {
+		result, err := FindDown("node_modules/", &Options{Cwd: nodeModules, Type: DirectoryType, IncludeSelf: true})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != nodeModules {
+			t.Errorf("Expected %s, got %s", nodeModules, result)
+		}
+	})
+}
+
+func TestRootAnchoredGlob(t *testing.T) {
+	// tempDir/                  (StopAt)
+	//   ├── package.json
+	//   └── packages/
+	//       ├── package.json
+	//       └── app/
+	//           ├── package.json
+	//           └── src/
+	//               └── package.json
+	tempDir := createTestTree(t,
+		"package.json",
+		"packages/package.json",
+		"packages/app/package.json",
+		"packages/app/src/package.json",
+	)
+	src := filepath.Join(tempDir, "packages", "app", "src")
+
+	t.Run("FindUp with root-anchored pattern", func(t *testing.T) {
+		result, err := FindUp("packages/*/package.json", &Options{Cwd: src, StopAt: tempDir, RootAnchoredGlob: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "packages", "app", "package.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUpMultiple with root-anchored pattern", func(t *testing.T) {
+		results, err := FindUpMultiple("packages/*", &Options{Cwd: src, StopAt: tempDir, RootAnchoredGlob: true, Type: BothType})
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "packages", "app"), filepath.Join(tempDir, "packages", "package.json")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("RootAnchoredGlob without StopAt", func(t *testing.T) {
+		_, err := FindUp("packages/*/package.json", &Options{Cwd: src, RootAnchoredGlob: true})
+		if err == nil {
+			t.Error("Expected error without StopAt")
+		}
+	})
+}
+
+func TestStopAtFirstLevel(t *testing.T) {
+	// tempDir/
+	//   ├── base.yaml
+	//   └── app/
+	//       ├── app.yaml
+	//       ├── local.yaml
+	//       └── src/
+	tempDir := createTestTree(t, "base.yaml", "app/app.yaml", "app/local.yaml", "app/src/")
+	src := filepath.Join(tempDir, "app", "src")
+
+	t.Run("FindUpMultiple with StopAtFirstLevel", func(t *testing.T) {
+		results, err := FindUpMultiple("*.yaml", &Options{Cwd: src, StopAtFirstLevel: true})
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "app", "app.yaml"), filepath.Join(tempDir, "app", "local.yaml")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindUpMultiple without StopAtFirstLevel", func(t *testing.T) {
+		results, err := FindUpMultiple("*.yaml", &Options{Cwd: src, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		if len(results) != 3 {
+			t.Errorf("Expected 3 results, got %v", results)
+		}
+	})
+}
+
+func TestNameMatch(t *testing.T) {
+	// tempDir/
+	//   ├── Docker_File
+	//   └── app/
+	//       ├── docker-compose.yml
+	//       └── src/
+	tempDir := createTestTree(t, "Docker_File", "app/docker-compose.yml", "app/src/")
+	src := filepath.Join(tempDir, "app", "src")
+
+	// fuzzy ignores case, dashes and underscores
+	fuzzy := func(want string) func(entryName string) bool {
+		return func(entryName string) bool {
+			normalized := strings.NewReplacer("-", "", "_", "").Replace(entryName)
+			return strings.EqualFold(normalized, want)
+		}
+	}
+
+	t.Run("FindUp with NameMatch", func(t *testing.T) {
+		result, err := FindUp("", &Options{Cwd: src, NameMatch: fuzzy("dockerfile")})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "Docker_File")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownMultiple with NameMatch", func(t *testing.T) {
+		results, err := FindDownMultiple("", &Options{Cwd: tempDir, Depth: -1, NameMatch: fuzzy("dockercompose.yml")})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "app", "docker-compose.yml")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("NameMatch still applies Type", func(t *testing.T) {
+		result, err := FindUp("", &Options{Cwd: src, StopAt: filepath.Dir(tempDir), Type: DirectoryType, NameMatch: fuzzy("dockerfile")})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestOnPrune(t *testing.T) {
+	// tempDir/
+	//   ├── node_modules/
+	//   │   └── index.js
+	//   └── src/
+	//       ├── index.js
+	//       └── deep/
+	//           └── index.js
+	tempDir := createTestTree(t, "node_modules/index.js", "src/index.js", "src/deep/index.js")
+
+	pruned := make(map[string]string)
+	results, err := FindDownMultiple("index.js", &Options{
+		Cwd:     tempDir,
+		Depth:   1,
+		Exclude: []string{"node_*"},
+		OnPrune: func(dir string, reason string) {
+			pruned[dir] = reason
+		},
+	})
+	if err != nil {
+		t.Fatalf("FindDownMultiple failed: %v", err)
+	}
+
+	expected := []string{filepath.Join(tempDir, "src", "index.js")}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %v, got %v", expected, results)
+	}
+
+	expectedPruned := map[string]string{
+		filepath.Join(tempDir, "node_modules"): "excluded",
+		filepath.Join(tempDir, "src", "deep"):  "depth",
+	}
+	if !reflect.DeepEqual(pruned, expectedPruned) {
+		t.Errorf("Expected pruned directories %v, got %v", expectedPruned, pruned)
+	}
+}
+
+func TestExtGlob(t *testing.T) {
+	// tempDir/
+	//   ├── app.js
+	//   ├── package.json
+	//   └── src/
+	//       ├── main.ts
+	//       └── README
+	tempDir := createTestTree(t, "app.js", "package.json", "src/main.ts", "src/README")
+
+	t.Run("FindDownMultiple with ExtGlob", func(t *testing.T) {
+		results, err := FindDownMultiple("*", &Options{Cwd: tempDir, Depth: -1, ExtGlob: ".[jt]s"})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "app.js"), filepath.Join(tempDir, "src", "main.ts")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("ExtGlob with invalid pattern", func(t *testing.T) {
+		_, err := FindUp("app.js", &Options{Cwd: tempDir, ExtGlob: "["})
+		if err == nil {
+			t.Error("Expected error for invalid pattern")
+		}
+	})
+}
+
+func TestFindUpUnreadableDirectory(t *testing.T) {
+	// tempDir/
+	//   ├── config.json
+	//   └── locked/        (not listable)
+	//       ├── config.json
+	//       └── child/
+	tempDir := createTestTree(t, "config.json", "locked/config.json", "locked/child/")
+	locked := filepath.Join(tempDir, "locked")
+	cwd := filepath.Join(locked, "child")
+
+	// Listing locked fails the way a directory without read permission does, whoever runs the tests
+	readDir := func(dir string) ([]fs.DirEntry, error) {
+		if dir == locked {
+			return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrPermission}
+		}
+		return os.ReadDir(dir)
+	}
+
+	t.Run("FindUp continues past the unreadable directory by default", func(t *testing.T) {
+		result, err := FindUp("*.json", &Options{Cwd: cwd, ReadDirFunc: readDir})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUpMultiple reports the unreadable directory to OnError", func(t *testing.T) {
+		var skipped []string
+		_, err := FindUpMultiple("*.json", &Options{
+			Cwd:         cwd,
+			ReadDirFunc: readDir,
+			OnError: func(dir string, err error) error {
+				skipped = append(skipped, dir)
+				return err
+			},
+		})
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Expected permission error, got %v", err)
+		}
+		if len(skipped) != 1 || skipped[0] != locked {
+			t.Errorf("Expected only %s to be reported, got %v", locked, skipped)
+		}
+	})
+
+	t.Run("FindUpAnyMultiple reports the unreadable directory once", func(t *testing.T) {
+		var skipped []string
+		groups, err := FindUpAnyMultiple([]string{"config.json", "*.json"}, &Options{
+			Cwd:         cwd,
+			StopAt:      filepath.Dir(tempDir),
+			ReadDirFunc: readDir,
+			OnError: func(dir string, err error) error {
+				skipped = append(skipped, dir)
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("FindUpAnyMultiple failed: %v", err)
+		}
+		if len(skipped) != 1 || skipped[0] != locked {
+			t.Errorf("Expected only %s to be reported, got %v", locked, skipped)
+		}
+
+		// The skipped directory contributes no matches to any name
+		expected := []NameMatches{
+			{Name: "config.json", Paths: []string{filepath.Join(tempDir, "config.json")}},
+			{Name: "*.json", Paths: []string{filepath.Join(tempDir, "config.json")}},
+		}
+		if !reflect.DeepEqual(groups, expected) {
+			t.Errorf("Expected %v, got %v", expected, groups)
+		}
+	})
+}
+
+func TestFirstExisting(t *testing.T) {
+	// tempDir/
+	//   ├── etc/
+	//   │   └── app.conf
+	//   └── home/
+	//       └── .config/
+	//           └── app.conf/
+	tempDir := createTestTree(t, "etc/app.conf", "home/.config/app.conf/")
+	candidates := []string{
+		filepath.Join(tempDir, "missing", "app.conf"),
+		filepath.Join(tempDir, "home", ".config", "app.conf"),
+		filepath.Join(tempDir, "etc", "app.conf"),
+	}
+
+	t.Run("FirstExisting skips missing paths and other types", func(t *testing.T) {
+		result, err := FirstExisting(candidates, &Options{Type: FileType})
+		if err != nil {
+			t.Fatalf("FirstExisting failed: %v", err)
+		}
+		if result != candidates[2] {
+			t.Errorf("Expected %s, got %s", candidates[2], result)
+		}
+	})
+
+	t.Run("FirstExisting keeps the given order", func(t *testing.T) {
+		result, err := FirstExisting(candidates, &Options{Type: BothType})
+		if err != nil {
+			t.Fatalf("FirstExisting failed: %v", err)
+		}
+		if result != candidates[1] {
+			t.Errorf("Expected %s, got %s", candidates[1], result)
+		}
+	})
+
+	t.Run("FirstExisting with relative paths", func(t *testing.T) {
+		result, err := FirstExisting([]string{"missing.conf", filepath.Join("etc", "app.conf")}, &Options{Cwd: tempDir})
+		if err != nil {
+			t.Fatalf("FirstExisting failed: %v", err)
+		}
+		if result != candidates[2] {
+			t.Errorf("Expected %s, got %s", candidates[2], result)
+		}
+	})
+
+	t.Run("FirstExisting without match", func(t *testing.T) {
+		result, err := FirstExisting(candidates[:1], nil)
+		if err != nil {
+			t.Fatalf("FirstExisting failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestFindUpBoundary(t *testing.T) {
+	// tempDir/
+	//   ├── .git        (file)
+	//   └── repo/
+	//       ├── .git/
+	//       └── pkg/
+	//           └── sub/
+	tempDir := createTestTree(t, ".git", "repo/.git/", "repo/pkg/sub/")
+	repo := filepath.Join(tempDir, "repo")
+
+	t.Run("FindUpBoundary returns the directory containing .git", func(t *testing.T) {
+		result, err := FindUpBoundary(".git", &Options{Cwd: filepath.Join(repo, "pkg", "sub")})
+		if err != nil {
+			t.Fatalf("FindUpBoundary failed: %v", err)
+		}
+		if result != repo {
+			t.Errorf("Expected %s, got %s", repo, result)
+		}
+	})
+
+	t.Run("FindUpBoundary only considers directories", func(t *testing.T) {
+		result, err := FindUpBoundary(".git", &Options{Cwd: tempDir, StopAt: filepath.Dir(tempDir), Type: FileType})
+		if err != nil {
+			t.Fatalf("FindUpBoundary failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestConfineSymlinks(t *testing.T) {
+	// tempDir/
+	//   ├── outside/
+	//   │   ├── secret.txt
+	//   │   └── sub/
+	//   └── sandbox/       (StopAt)
+	//       ├── link -> ../outside
+	//       └── inner/
+	//           ├── marker.txt
+	//           └── sub/
+	tempDir := createTestTree(t, "outside/secret.txt", "outside/sub/", "sandbox/inner/marker.txt", "sandbox/inner/sub/")
+	sandbox := filepath.Join(tempDir, "sandbox")
+	if err := os.Symlink(filepath.Join("..", "outside"), filepath.Join(sandbox, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	escaping := filepath.Join(sandbox, "link", "sub")
+
+	t.Run("FindUp follows the escaping symlink by default", func(t *testing.T) {
+		result, err := FindUp("secret.txt", &Options{Cwd: escaping, StopAt: sandbox})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(sandbox, "link", "secret.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindUp with ConfineSymlinks halts at the escaping symlink", func(t *testing.T) {
+		result, err := FindUp("secret.txt", &Options{Cwd: escaping, StopAt: sandbox, ConfineSymlinks: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindUp with ConfineSymlinks inside StopAt", func(t *testing.T) {
+		result, err := FindUp("marker.txt", &Options{Cwd: filepath.Join(sandbox, "inner", "sub"), StopAt: sandbox, ConfineSymlinks: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(sandbox, "inner", "marker.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestBothTypePreference(t *testing.T) {
+	// tempDir/
+	//   ├── config/
+	//   ├── config.d/
+	//   ├── config.json
+	//   └── dir1/
+	tempDir := createTestTree(t, "config/", "config.d/", "config.json", "dir1/")
+	cwd := filepath.Join(tempDir, "dir1")
+
+	tests := []struct {
+		name       string
+		preference TypePreference
+		expected   string
+	}{
+		{"NoPreference", NoPreference, "config"},
+		{"FilesFirst", FilesFirst, "config.json"},
+		{"DirsFirst", DirsFirst, "config"},
+	}
+
+	for _, tt := range tests {
+		t.Run("FindUp with "+tt.name, func(t *testing.T) {
+			result, err := FindUp("config*", &Options{Cwd: cwd, Type: BothType, BothTypePreference: tt.preference})
+			if err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+			expected := filepath.Join(tempDir, tt.expected)
+			if result != expected {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+		})
+	}
+
+	t.Run("DirsFirst prefers a symlinked directory", func(t *testing.T) {
+		linkDir := createTestTree(t, "cfg-a", "target/")
+		if err := os.Symlink(filepath.Join(linkDir, "target"), filepath.Join(linkDir, "cfg-b")); err != nil {
+			t.Skip("Symlinks not supported")
+		}
+		options := &Options{Cwd: linkDir, Type: BothType, BothTypePreference: DirsFirst, AllowSymlinks: true}
+		result, err := FindUp("cfg-*", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(linkDir, "cfg-b")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("type lookups are traced", func(t *testing.T) {
+		countStats := func(preference TypePreference) int {
+			tracer := &recordingTracer{}
+			options := &Options{Cwd: cwd, Type: BothType, BothTypePreference: preference, Tracer: tracer}
+			if _, err := FindUp("config*", options); err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+			stats := 0
+			for _, event := range tracer.events {
+				if event.op == "stat" {
+					stats++
+				}
+			}
+			return stats
+		}
+
+		// Ordering the three matches looks up the type of each of them
+		if without, with := countStats(NoPreference), countStats(DirsFirst); with != without+3 {
+			t.Errorf("Expected 3 more traced stats with DirsFirst, got %d and %d", without, with)
+		}
+	})
+
+	t.Run("FindDown with FilesFirst", func(t *testing.T) {
+		result, err := FindDown("config*", &Options{Cwd: tempDir, Type: BothType, BothTypePreference: FilesFirst})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestFindDownUniqueNames(t *testing.T) {
+	// tempDir/
+	//   ├── main.go
+	//   ├── util.go
+	//   ├── cmd/
+	//   │   └── main.go
+	//   └── internal/
+	//       ├── main.go
+	//       └── api.go
+	tempDir := createTestTree(t, "main.go", "util.go", "cmd/main.go", "internal/main.go", "internal/api.go")
+
+	t.Run("FindDownUniqueNames collapses duplicates", func(t *testing.T) {
+		results, err := FindDownUniqueNames("*.go", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownUniqueNames failed: %v", err)
+		}
+		expected := []string{"api.go", "main.go", "util.go"}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownUniqueNames without matches", func(t *testing.T) {
+		results, err := FindDownUniqueNames("*.rs", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownUniqueNames failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no names, got %v", results)
+		}
+	})
+}
+
+func TestNameFunc(t *testing.T) {
+	// tempDir/
+	//   └── app/
+	//       ├── app.config
+	//       ├── src.config
+	//       └── src/
+	//           └── lib/
+	//               └── lib.config
+	tempDir := createTestTree(t, "app/app.config", "app/src.config", "app/src/lib/lib.config")
+	app := filepath.Join(tempDir, "app")
+
+	// Each directory looks for a file named after itself
+	ownConfig := func(dir string) string {
+		return filepath.Base(dir) + ".config"
+	}
+
+	t.Run("FindUp with NameFunc", func(t *testing.T) {
+		result, err := FindUp("", &Options{Cwd: filepath.Join(app, "src"), NameFunc: ownConfig})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(app, "app.config")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownMultiple with NameFunc", func(t *testing.T) {
+		results, err := FindDownMultiple("", &Options{Cwd: app, Depth: -1, NameFunc: ownConfig})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(app, "app.config"), filepath.Join(app, "src", "lib", "lib.config")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestContentRegexp(t *testing.T) {
+	// tempDir/
+	//   ├── go.mod        (module example.com/root)
+	//   └── nested/
+	//       ├── go.mod    (module other.org/nested)
+	//       └── src/
+	tempDir := createTestTree(t, "nested/src/")
+	nested := filepath.Join(tempDir, "nested")
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("// root module\nmodule example.com/root\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "go.mod"), []byte("module other.org/nested\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+	re := regexp.MustCompile(`(?m)^module example\.com/`)
+
+	t.Run("FindUp with ContentRegexp", func(t *testing.T) {
+		result, err := FindUp("go.mod", &Options{Cwd: filepath.Join(nested, "src"), ContentRegexp: re})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "go.mod")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("ContentRegexp beyond MaxContentScanBytes", func(t *testing.T) {
+		result, err := FindUp("go.mod", &Options{
+			Cwd:                 filepath.Join(nested, "src"),
+			StopAt:              filepath.Dir(tempDir),
+			ContentRegexp:       re,
+			MaxContentScanBytes: 10,
+		})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestFindUpInFindDownIn(t *testing.T) {
+	// tempDir/
+	//   ├── config.json
+	//   ├── dir1/
+	//   │   └── config.json
+	//   └── dir2/
+	//       └── sub/
+	tempDir := createTestTree(t, "config.json", "dir1/config.json", "dir2/sub/")
+	options := &Options{Cwd: filepath.Join(tempDir, "dir1"), Depth: -1}
+	original := *options
+
+	t.Run("FindUpIn uses the given cwd", func(t *testing.T) {
+		result, err := FindUpIn(filepath.Join(tempDir, "dir2", "sub"), "config.json", options)
+		if err != nil {
+			t.Fatalf("FindUpIn failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownIn uses the given cwd", func(t *testing.T) {
+		result, err := FindDownIn(filepath.Join(tempDir, "dir2"), "sub", &Options{Type: DirectoryType})
+		if err != nil {
+			t.Fatalf("FindDownIn failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "dir2", "sub")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownIn with nil options", func(t *testing.T) {
+		result, err := FindDownIn(tempDir, "config.json", nil)
+		if err != nil {
+			t.Fatalf("FindDownIn failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config.json")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	if !reflect.DeepEqual(*options, original) {
+		t.Errorf("Expected options to be unmodified, got %+v", *options)
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	decomposed := "cafe\u0301.txt"
+	composed := "caf\u00e9.txt"
+
+	// tempDir/
+	//   ├── café.txt   (stored decomposed, "e" followed by a combining acute accent)
+	//   └── dir1/
+	tempDir := createTestTree(t, decomposed, "dir1/")
+	expected := filepath.Join(tempDir, decomposed)
+
+	t.Run("FindUp exact name without normalization", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "dir1"), StopAt: tempDir}
+		result, err := FindUp(composed, options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("FindUp exact name", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "dir1"), NormalizeUnicode: true}
+		result, err := FindUp(composed, options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDown glob pattern", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, NormalizeUnicode: true}
+		result, err := FindDown("caf\u00e9.*", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestFindUpEach(t *testing.T) {
+	// tempDir/
+	//   ├── .editorconfig
+	//   └── dir1/
+	//       ├── .editorconfig
+	//       └── dir2/
+	//           ├── .editorconfig
+	//           └── dir3/
+	tempDir := createTestTree(t, ".editorconfig", "dir1/.editorconfig", "dir1/dir2/.editorconfig", "dir1/dir2/dir3/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2", "dir3")
+
+	type visit struct {
+		path  string
+		depth int
+	}
+
+	t.Run("stop after the second match", func(t *testing.T) {
+		var visits []visit
+		options := &Options{Cwd: cwd}
+		err := FindUpEach(".editorconfig", options, func(path string, depth int) (bool, error) {
+			visits = append(visits, visit{path, depth})
+			return len(visits) == 2, nil
+		})
+		if err != nil {
+			t.Fatalf("FindUpEach failed: %v", err)
+		}
+		expected := []visit{
+			{filepath.Join(tempDir, "dir1", "dir2", ".editorconfig"), 1},
+			{filepath.Join(tempDir, "dir1", ".editorconfig"), 2},
+		}
+		if !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected %v, got %v", expected, visits)
+		}
+	})
+
+	t.Run("every match up to StopAt", func(t *testing.T) {
+		count := 0
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		err := FindUpEach(".editorconfig", options, func(path string, depth int) (bool, error) {
+			count++
+			return false, nil
+		})
+		if err != nil {
+			t.Fatalf("FindUpEach failed: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("Expected 3 matches, got %d", count)
+		}
+	})
+
+	t.Run("error from fn", func(t *testing.T) {
+		errStop := errors.New("stop")
+		options := &Options{Cwd: cwd}
+		err := FindUpEach(".editorconfig", options, func(path string, depth int) (bool, error) {
+			return false, errStop
+		})
+		if err != errStop {
+			t.Errorf("Expected errStop, got %v", err)
+		}
+	})
+}
+
+func TestStopAtAny(t *testing.T) {
+	// tempDir/
+	//   ├── config.json
+	//   └── repo/
+	//       ├── config.json
+	//       └── workspace/
+	//           └── pkg/
+	//               └── src/
+	tempDir := createTestTree(t, "config.json", "repo/config.json", "repo/workspace/pkg/src/")
+	repo := filepath.Join(tempDir, "repo")
+	workspace := filepath.Join(repo, "workspace")
+	cwd := filepath.Join(workspace, "pkg", "src")
+
+	t.Run("halts at the nearer candidate", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAtAny: []string{repo, workspace}}
+		result, err := FindUp("config.json", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("halts at the only candidate reached", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAtAny: []string{tempDir, filepath.Join(tempDir, "other")}}
+		results, err := FindUpMultiple("config.json", options)
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(repo, "config.json")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("combined with StopAt", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: repo, StopAtAny: []string{tempDir}}
+		result, err := FindUp("config.json", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+}
+
+func TestFindUpThenDown(t *testing.T) {
+	// tempDir/
+	//   └── workspace/
+	//       ├── pnpm-workspace.yaml
+	//       └── packages/
+	//           ├── app/
+	//           │   ├── package.json
+	//           │   └── src/
+	//           └── lib/
+	//               └── package.json
+	tempDir := createTestTree(t,
+		"workspace/pnpm-workspace.yaml",
+		"workspace/packages/app/package.json",
+		"workspace/packages/app/src/",
+		"workspace/packages/lib/package.json",
+	)
+	root := filepath.Join(tempDir, "workspace")
+	cwd := filepath.Join(root, "packages", "app", "src")
+
+	t.Run("absolute downward matches", func(t *testing.T) {
+		options := &Options{Cwd: cwd, Depth: -1}
+		results, err := FindUpThenDown("pnpm-workspace.yaml", "package.json", options)
+		if err != nil {
+			t.Fatalf("FindUpThenDown failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(root, "packages", "app", "package.json"),
+			filepath.Join(root, "packages", "lib", "package.json"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("relative to the project root", func(t *testing.T) {
+		options := &Options{Cwd: cwd, Depth: -1, RelativeToMatch: true}
+		results, err := FindUpThenDown("pnpm-workspace.yaml", "package.json", options)
+		if err != nil {
+			t.Fatalf("FindUpThenDown failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join("packages", "app", "package.json"),
+			filepath.Join("packages", "lib", "package.json"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("no marker", func(t *testing.T) {
+		options := &Options{Cwd: cwd, Depth: -1, StopAt: filepath.Dir(tempDir)}
+		results, err := FindUpThenDown("lerna.json", "package.json", options)
+		if err != nil {
+			t.Fatalf("FindUpThenDown failed: %v", err)
+		}
+		if results != nil {
+			t.Errorf("Expected no results, got %v", results)
+		}
+	})
+}
+
+func TestFallbackToResolvedCwd(t *testing.T) {
+	// tempDir/
+	//   ├── real/
+	//   │   └── project/
+	//   │       ├── package.json
+	//   │       └── pkg/
+	//   └── links/
+	//       └── pkg -> ../real/project/pkg
+	tempDir := createTestTree(t, "real/project/package.json", "real/project/pkg/", "links/")
+	if err := os.Symlink(filepath.Join(tempDir, "real", "project", "pkg"), filepath.Join(tempDir, "links", "pkg")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	cwd := filepath.Join(tempDir, "links", "pkg")
+
+	t.Run("logical walk only", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		result, err := FindUp("package.json", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("fallback to the resolved path", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), FallbackToResolvedCwd: true}
+		result, err := FindUp("package.json", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected, err := filepath.EvalSymlinks(filepath.Join(tempDir, "real", "project", "package.json"))
+		if err != nil {
+			t.Fatalf("EvalSymlinks failed: %v", err)
+		}
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestGlobCaseInsensitive(t *testing.T) {
+	// tempDir/
+	//   ├── photo.jpg
+	//   ├── Makefile
+	//   └── dir1/
+	tempDir := createTestTree(t, "photo.jpg", "Makefile", "dir1/")
+
+	tests := []struct {
+		name     string
+		pattern  string
+		fold     bool
+		expected string
+	}{
+		{"glob is case-sensitive by default", "*.JPG", false, ""},
+		{"glob ignores case", "*.JPG", true, filepath.Join(tempDir, "photo.jpg")},
+		{"exact name stays case-sensitive", "makefile", true, ""},
+		{"exact name", "Makefile", true, filepath.Join(tempDir, "Makefile")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{Cwd: filepath.Join(tempDir, "dir1"), StopAt: filepath.Dir(tempDir), GlobCaseInsensitive: tt.fold}
+			result, err := FindUp(tt.pattern, options)
+			if err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFindUpAnySet(t *testing.T) {
+	// tempDir/
+	//   ├── package.json
+	//   └── dir1/
+	//       ├── Cargo.toml
+	//       ├── go.mod
+	//       ├── docs/
+	//       └── dir2/
+	tempDir := createTestTree(t, "package.json", "dir1/Cargo.toml", "dir1/go.mod", "dir1/docs/", "dir1/dir2/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2")
+
+	t.Run("first name in the nearest directory", func(t *testing.T) {
+		names := map[string]struct{}{"go.mod": {}, "Cargo.toml": {}, "package.json": {}}
+		match, err := FindUpAnySet(names, &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpAnySet failed: %v", err)
+		}
+		expected := Match{Path: filepath.Join(tempDir, "dir1", "Cargo.toml"), MatchedName: "Cargo.toml"}
+		if match != expected {
+			t.Errorf("Expected %+v, got %+v", expected, match)
+		}
+	})
+
+	t.Run("type is checked", func(t *testing.T) {
+		names := map[string]struct{}{"docs": {}, "package.json": {}}
+		match, err := FindUpAnySet(names, &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpAnySet failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "package.json")
+		if match.Path != expected {
+			t.Errorf("Expected %s, got %s", expected, match.Path)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		names := map[string]struct{}{"pom.xml": {}}
+		match, err := FindUpAnySet(names, &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpAnySet failed: %v", err)
+		}
+		if match.Path != "" {
+			t.Errorf("Expected no match, got %s", match.Path)
+		}
+	})
+}
+
+func BenchmarkFindUpAnyManyNames(b *testing.B) {
+	tempDir := createTestTree(b, "marker.txt", "dir1/dir2/dir3/")
+	cwd := filepath.Join(tempDir, "dir1", "dir2", "dir3")
+
+	names := make([]string, 0, 200)
+	set := make(map[string]struct{}, 200)
+	for i := 0; i < 199; i++ {
+		name := fmt.Sprintf("candidate-%d.conf", i)
+		names = append(names, name)
+		set[name] = struct{}{}
+	}
+	names = append(names, "marker.txt")
+	set["marker.txt"] = struct{}{}
+
+	b.Run("FindUpAny", func(b *testing.B) {
+		options := &Options{Cwd: cwd}
+		for i := 0; i < b.N; i++ {
+			if _, err := FindUpAny(names, options); err != nil {
+				b.Fatalf("FindUpAny failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("FindUpAnySet", func(b *testing.B) {
+		options := &Options{Cwd: cwd}
+		for i := 0; i < b.N; i++ {
+			if _, err := FindUpAnySet(set, options); err != nil {
+				b.Fatalf("FindUpAnySet failed: %v", err)
+			}
+		}
+	})
+}
+
+func TestDepthZero(t *testing.T) {
+	// tempDir/
+	//   ├── file.txt
+	//   └── dir1/
+	//       ├── file.txt
+	//       └── dir2/
+	//           └── file.txt
+	tempDir := createTestTree(t, "file.txt", "dir1/file.txt", "dir1/dir2/file.txt")
+
+	tests := []struct {
+		name     string
+		depth    int
+		expected []string
+	}{
+		{"Depth 0 matches only Cwd", 0, []string{filepath.Join(tempDir, "file.txt")}},
+		{"Depth 1 descends one level", 1, []string{filepath.Join(tempDir, "file.txt"), filepath.Join(tempDir, "dir1", "file.txt")}},
+		{"negative Depth has no limit", -1, []string{
+			filepath.Join(tempDir, "file.txt"),
+			filepath.Join(tempDir, "dir1", "file.txt"),
+			filepath.Join(tempDir, "dir1", "dir2", "file.txt"),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{Cwd: tempDir, Depth: tt.depth}
+			results, err := FindDownMultiple("file.txt", options)
+			if err != nil {
+				t.Fatalf("FindDownMultiple failed: %v", err)
+			}
+			if !reflect.DeepEqual(results, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, results)
+			}
+		})
+	}
+
+	t.Run("FindDown with Depth 0 skips descendants", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: 0, Type: DirectoryType}
+		result, err := FindDown("dir2", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+}
+
+func TestMatchPathWithoutExt(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{filepath.Join("src", "config.json"), filepath.Join("src", "config")},
+		{filepath.Join("src", "app.test.ts"), filepath.Join("src", "app.test")},
+		{filepath.Join("src", "Makefile"), filepath.Join("src", "Makefile")},
+		{filepath.Join("src", ".env"), filepath.Join("src", ".env")},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if result := (Match{Path: tt.path}).PathWithoutExt(); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("FindUpAny match", func(t *testing.T) {
+		// tempDir/
+		//   ├── config.yaml
+		//   └── dir1/
+		tempDir := createTestTree(t, "config.yaml", "dir1/")
+		match, err := FindUpAny([]string{"config.json", "config.yaml"}, &Options{Cwd: filepath.Join(tempDir, "dir1")})
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "config")
+		if result := match.PathWithoutExt(); result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestNewerThanFile(t *testing.T) {
+	// tempDir/
+	//   ├── build.stamp    (1 hour ago)
+	//   └── src/
+	//       ├── old.go     (2 hours ago)
+	//       ├── new.go     (now)
+	//       └── pkg/
+	//           └── lib.go (now)
+	tempDir := createTestTree(t, "build.stamp", "src/old.go", "src/new.go", "src/pkg/lib.go")
+
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"build.stamp": time.Hour,
+		"src/old.go":  2 * time.Hour,
+	}
+	for name, age := range ages {
+		modTime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(tempDir, name), modTime, modTime); err != nil {
+			t.Fatalf("Failed to age %s: %v", name, err)
+		}
+	}
+
+	t.Run("FindDownMultiple newer than the stamp", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "src"), Depth: -1, NewerThanFile: filepath.Join(tempDir, "build.stamp")}
+		results, err := FindDownMultiple("*.go", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "src", "new.go"),
+			filepath.Join(tempDir, "src", "pkg", "lib.go"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("reference relative to Cwd", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, NewerThanFile: "build.stamp"}
+		results, err := FindDownMultiple("old.go", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results, got %v", results)
+		}
+	})
+
+	t.Run("missing reference file", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, NewerThanFile: "missing.stamp"}
+		if _, err := FindDownMultiple("*.go", options); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected fs.ErrNotExist, got %v", err)
+		}
+	})
+}
+
+func TestSkipDotfiles(t *testing.T) {
+	// tempDir/
+	//   ├── .hidden
+	//   └── dir1/
+	tempDir := createTestTree(t, ".hidden", "dir1/")
+	cwd := filepath.Join(tempDir, "dir1")
+	hidden := filepath.Join(tempDir, ".hidden")
+
+	tests := []struct {
+		name     string
+		pattern  string
+		options  *Options
+		expected string
+	}{
+		{"star matches dotfiles", "*", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}, hidden},
+		{"star skips dotfiles", "*", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), SkipDotfiles: true}, ""},
+		{"question mark skips dotfiles", "?hidden", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), SkipDotfiles: true}, ""},
+		{"leading dot still matches", ".h*", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), SkipDotfiles: true}, hidden},
+		{"exact dotfile name", ".hidden", &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), SkipDotfiles: true}, hidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FindUp(tt.pattern, tt.options)
+			if err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("DefaultOptions match dotfiles", func(t *testing.T) {
+		options := DefaultOptions()
+		options.Cwd = tempDir
+		result, err := FindDown("*", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if result != hidden {
+			t.Errorf("Expected %s, got %s", hidden, result)
+		}
+	})
+}
+
+func TestPrepareUp(t *testing.T) {
+	// tempDir/
+	//   ├── go.mod
+	//   ├── .editorconfig
+	//   └── dir1/
+	//       ├── package.json
+	//       └── dir2/
+	tempDir := createTestTree(t, "go.mod", ".editorconfig", "dir1/package.json", "dir1/dir2/")
+
+	lookup, err := PrepareUp(&Options{Cwd: filepath.Join(tempDir, "dir1", "dir2"), StopAt: filepath.Dir(tempDir)})
+	if err != nil {
+		t.Fatalf("PrepareUp failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"go.mod", filepath.Join(tempDir, "go.mod")},
+		{"package.json", filepath.Join(tempDir, "dir1", "package.json")},
+		{".editorconfig", filepath.Join(tempDir, ".editorconfig")},
+		{"*.json", filepath.Join(tempDir, "dir1", "package.json")},
+		{"Cargo.toml", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := lookup(tt.name)
+			if err != nil {
+				t.Fatalf("lookup failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("files created after preparing", func(t *testing.T) {
+		created := filepath.Join(tempDir, "dir1", "dir2", "Cargo.toml")
+		if err := os.WriteFile(created, []byte("[package]"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		result, err := lookup("Cargo.toml")
+		if err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+		if result != created {
+			t.Errorf("Expected %s, got %s", created, result)
+		}
+	})
+}
+
+func TestPatternsFromFile(t *testing.T) {
+	// tempDir/
+	//   ├── .lintpatterns
+	//   ├── main.go
+	//   ├── README.md
+	//   ├── Makefile
+	//   └── pkg/
+	//       ├── lib.go
+	//       └── lib_test.go
+	tempDir := createTestTree(t, "main.go", "README.md", "Makefile", "pkg/lib.go", "pkg/lib_test.go")
+	patterns := "# files to lint\n*.go\n\nMakefile\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".lintpatterns"), []byte(patterns), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	t.Run("FindDownMultiple with a patterns file", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, PatternsFromFile: ".lintpatterns"}
+		results, err := FindDownMultiple("*", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "Makefile"),
+			filepath.Join(tempDir, "main.go"),
+			filepath.Join(tempDir, "pkg", "lib.go"),
+			filepath.Join(tempDir, "pkg", "lib_test.go"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("combined with ExcludeNames", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, PatternsFromFile: ".lintpatterns", ExcludeNames: []string{"*_test.go"}}
+		results, err := FindDownMultiple("*.go", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "main.go"), filepath.Join(tempDir, "pkg", "lib.go")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(tempDir, "bad.patterns"), []byte("[\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		options := &Options{Cwd: tempDir, Depth: -1, PatternsFromFile: "bad.patterns"}
+		if _, err := FindDownMultiple("*", options); err == nil {
+			t.Error("Expected an error for an invalid pattern")
+		}
+	})
+}
+
+func TestFindDownDepthHistogram(t *testing.T) {
+	// tempDir/
+	//   ├── a.md
+	//   ├── dir1/
+	//   │   ├── b.md
+	//   │   ├── c.md
+	//   │   └── dir2/
+	//   │       └── d.md
+	//   └── dir3/
+	//       ├── e.md
+	//       └── dir4/
+	//           ├── f.md
+	//           └── g.md
+	tempDir := createTestTree(t,
+		"a.md",
+		"dir1/b.md",
+		"dir1/c.md",
+		"dir1/dir2/d.md",
+		"dir3/e.md",
+		"dir3/dir4/f.md",
+		"dir3/dir4/g.md",
+	)
+
+	t.Run("counts by depth", func(t *testing.T) {
+		histogram, err := FindDownDepthHistogram("*.md", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownDepthHistogram failed: %v", err)
+		}
+		expected := map[int]int{0: 1, 1: 3, 2: 3}
+		if !reflect.DeepEqual(histogram, expected) {
+			t.Errorf("Expected %v, got %v", expected, histogram)
+		}
+	})
+
+	t.Run("with StartDepth", func(t *testing.T) {
+		histogram, err := FindDownDepthHistogram("*.md", &Options{Cwd: tempDir, Depth: -1, StartDepth: 3})
+		if err != nil {
+			t.Fatalf("FindDownDepthHistogram failed: %v", err)
+		}
+		expected := map[int]int{0: 1, 1: 3, 2: 3}
+		if !reflect.DeepEqual(histogram, expected) {
+			t.Errorf("Expected %v, got %v", expected, histogram)
+		}
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		histogram, err := FindDownDepthHistogram("*.txt", &Options{Cwd: tempDir, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownDepthHistogram failed: %v", err)
+		}
+		if len(histogram) != 0 {
+			t.Errorf("Expected an empty histogram, got %v", histogram)
+		}
+	})
+}
+
+func TestStopAtMarkers(t *testing.T) {
+	// tempDir/
+	//   ├── .env
+	//   └── project/
+	//       ├── .stop-here
+	//       └── src/
+	//           └── pkg/
+	tempDir := createTestTree(t, ".env", "project/.stop-here", "project/src/pkg/")
+	cwd := filepath.Join(tempDir, "project", "src", "pkg")
+
+	t.Run("halts after the marker directory", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAtMarkers: []string{".git", ".stop-here"}}
+		result, err := FindUp(".env", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("marker directory is searched", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAtMarkers: []string{".stop-here"}}
+		result, err := FindUp(".stop-here", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "project", ".stop-here")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("no marker found", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), StopAtMarkers: []string{"go.work"}}
+		result, err := FindUp(".env", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, ".env")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
+
+func TestTargetGlob(t *testing.T) {
+	// tempDir/
+	//   ├── node_modules/
+	//   │   └── pkg/
+	//   ├── vendor/
+	//   │   └── pkg/
+	//   ├── file.txt
+	//   └── links/
+	//       ├── a -> ../node_modules/pkg
+	//       ├── b -> ../vendor/pkg
+	//       └── c -> ../node_modules/missing
+	tempDir := createTestTree(t, "node_modules/pkg/", "vendor/pkg/", "file.txt", "links/")
+	links := map[string]string{
+		"a": "../node_modules/pkg",
+		"b": "../vendor/pkg",
+		"c": "../node_modules/missing",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(tempDir, "links", name)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	t.Run("FindDownMultiple links into node_modules", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, Type: SymlinkType, TargetGlob: "../node_modules/*"}
+		results, err := FindDownMultiple("*", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "links", "a"),
+			filepath.Join(tempDir, "links", "c"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("regular files never match", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, Type: BothType, TargetGlob: "*"}
+		results, err := FindDownMultiple("file.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results, got %v", results)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, TargetGlob: "["}
+		if _, err := FindDownMultiple("*", options); err == nil {
+			t.Error("Expected an error for an invalid pattern")
+		}
+	})
+}
+
+func TestFindUpResult(t *testing.T) {
+	// tempDir/
+	//   ├── .eslintrc.json
+	//   ├── .prettierrc
+	//   ├── package.json
+	//   └── src/
+	//       └── components/
+	tempDir := createTestTree(t, ".eslintrc.json", ".prettierrc", "package.json", "src/components/")
+	cwd := filepath.Join(tempDir, "src", "components")
+
+	t.Run("siblings of the match", func(t *testing.T) {
+		options := &Options{Cwd: cwd, IncludeSiblings: true}
+		result, err := FindUpResult("package.json", options)
+		if err != nil {
+			t.Fatalf("FindUpResult failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "package.json")
+		if result.Path != expected || result.MatchedName != "package.json" {
+			t.Errorf("Expected %s matched by package.json, got %+v", expected, result.Match)
+		}
+		siblings := []string{".eslintrc.json", ".prettierrc", "src"}
+		if !reflect.DeepEqual(result.Siblings, siblings) {
+			t.Errorf("Expected siblings %v, got %v", siblings, result.Siblings)
+		}
+	})
+
+	t.Run("siblings not requested", func(t *testing.T) {
+		result, err := FindUpResult("package.json", &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpResult failed: %v", err)
+		}
+		if result.Siblings != nil {
+			t.Errorf("Expected no siblings, got %v", result.Siblings)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), IncludeSiblings: true}
+		result, err := FindUpResult("tsconfig.json", options)
+		if err != nil {
+			t.Fatalf("FindUpResult failed: %v", err)
+		}
+		if result.Path != "" || result.Siblings != nil {
+			t.Errorf("Expected an empty result, got %+v", result)
+		}
+	})
+}
+
+func TestMinDepth(t *testing.T) {
+	// tempDir/
+	// ├── target.txt
+	// └── a/
+	//     ├── target.txt
+	//     └── b/
+	//         ├── target.txt
+	//         └── c/
+	//             ├── target.txt
+	//             └── d/
+	//                 └── target.txt
+	tempDir := createTestTree(t, "target.txt", "a/target.txt", "a/b/target.txt", "a/b/c/target.txt", "a/b/c/d/target.txt")
+
+	t.Run("matches within range", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, MinDepth: 2, Depth: 3}
+		results, err := FindDownMultiple("target.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "a", "b", "target.txt"),
+			filepath.Join(tempDir, "a", "b", "c", "target.txt"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("first match at min depth", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, MinDepth: 2, Depth: -1}
+		result, err := FindDown("target.txt", options)
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "a", "b", "target.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("depth first", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, MinDepth: 3, Depth: -1, Strategy: DepthFirst}
+		results, err := FindDownMultiple("target.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "a", "b", "c", "target.txt"),
+			filepath.Join(tempDir, "a", "b", "c", "d", "target.txt"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestFindBoth(t *testing.T) {
+	// tempDir/
+	// ├── .editorconfig
+	// └── project/
+	//     ├── .editorconfig
+	//     └── src/
+	//         ├── .editorconfig
+	//         └── web/
+	//             └── .editorconfig
+	tempDir := createTestTree(t, ".editorconfig", "project/.editorconfig", "project/src/.editorconfig", "project/src/web/.editorconfig")
+	cwd := filepath.Join(tempDir, "project")
+
+	t.Run("matches above and below", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), Depth: -1}
+		results, err := FindBoth(".editorconfig", options)
+		if err != nil {
+			t.Fatalf("FindBoth failed: %v", err)
+		}
+		expected := []Match{
+			{Path: filepath.Join(cwd, ".editorconfig"), Direction: Up, Depth: 0},
+			{Path: filepath.Join(tempDir, ".editorconfig"), Direction: Up, Depth: -1},
+			{Path: filepath.Join(cwd, "src", ".editorconfig"), Direction: Down, Depth: 1},
+			{Path: filepath.Join(cwd, "src", "web", ".editorconfig"), Direction: Down, Depth: 2},
+		}
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d matches, got %+v", len(expected), results)
+		}
+		for i, match := range results {
+			if match.Path != expected[i].Path || match.Direction != expected[i].Direction || match.Depth != expected[i].Depth {
+				t.Errorf("Expected %+v, got %+v", expected[i], match)
+			}
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), Depth: -1}
+		results, err := FindBoth(".prettierrc", options)
+		if err != nil {
+			t.Fatalf("FindBoth failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no matches, got %+v", results)
+		}
+	})
+}
+
+func TestMaxTotalBytes(t *testing.T) {
+	// tempDir/
+	//   ├── a.txt  (40 bytes)
+	//   ├── b.txt  (30 bytes)
+	//   ├── c.txt  (20 bytes)
+	//   └── d.txt  (10 bytes)
+	tempDir := createTestTree(t)
+	sizes := map[string]int{"a.txt": 40, "b.txt": 30, "c.txt": 20, "d.txt": 10}
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		options  *Options
+		expected []string
+	}{
+		{"over budget excluded", &Options{Cwd: tempDir, MaxTotalBytes: 80}, []string{"a.txt", "b.txt"}},
+		{"over budget included", &Options{Cwd: tempDir, MaxTotalBytes: 80, IncludeOverBudget: true}, []string{"a.txt", "b.txt", "c.txt"}},
+		{"exact budget", &Options{Cwd: tempDir, MaxTotalBytes: 90}, []string{"a.txt", "b.txt", "c.txt"}},
+		{"no budget", &Options{Cwd: tempDir}, []string{"a.txt", "b.txt", "c.txt", "d.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := FindDownMultiple("*.txt", tt.options)
+			if err != nil {
+				t.Fatalf("FindDownMultiple failed: %v", err)
+			}
+			var expected []string
+			for _, name := range tt.expected {
+				expected = append(expected, filepath.Join(tempDir, name))
+			}
+			if !reflect.DeepEqual(results, expected) {
+				t.Errorf("Expected %v, got %v", expected, results)
+			}
+		})
+	}
+}
+
+func TestFindUpAnyMultiple(t *testing.T) {
+	// tempDir/
+	//   ├── .editorconfig
+	//   ├── package.json
+	//   └── apps/
+	//       ├── package.json
+	//       └── web/
+	//           ├── .editorconfig
+	//           └── src/
+	tempDir := createTestTree(t,
+		".editorconfig",
+		"package.json",
+		"apps/package.json",
+		"apps/web/.editorconfig",
+		"apps/web/src/",
+	)
+	cwd := filepath.Join(tempDir, "apps", "web", "src")
+
+	t.Run("grouped by name", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		groups, err := FindUpAnyMultiple([]string{"package.json", ".editorconfig", "tsconfig.json"}, options)
+		if err != nil {
+			t.Fatalf("FindUpAnyMultiple failed: %v", err)
+		}
+		expected := []NameMatches{
+			{Name: "package.json", Paths: []string{
+				filepath.Join(tempDir, "apps", "package.json"),
+				filepath.Join(tempDir, "package.json"),
+			}},
+			{Name: ".editorconfig", Paths: []string{
+				filepath.Join(tempDir, "apps", "web", ".editorconfig"),
+				filepath.Join(tempDir, ".editorconfig"),
+			}},
+			{Name: "tsconfig.json"},
+		}
+		if !reflect.DeepEqual(groups, expected) {
+			t.Errorf("Expected %v, got %v", expected, groups)
+		}
+	})
+
+	t.Run("with limit", func(t *testing.T) {
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir), Limit: 1}
+		groups, err := FindUpAnyMultiple([]string{".editorconfig", "package.json"}, options)
+		if err != nil {
+			t.Fatalf("FindUpAnyMultiple failed: %v", err)
+		}
+		expected := []NameMatches{
+			{Name: ".editorconfig", Paths: []string{filepath.Join(tempDir, "apps", "web", ".editorconfig")}},
+			{Name: "package.json", Paths: []string{filepath.Join(tempDir, "apps", "package.json")}},
+		}
+		if !reflect.DeepEqual(groups, expected) {
+			t.Errorf("Expected %v, got %v", expected, groups)
+		}
+	})
+}
+
+func TestAbortOnFile(t *testing.T) {
+	// tempDir/
+	//   ├── target.txt
+	//   ├── a/
+	//   │   └── target.txt
+	//   └── b/
+	//       └── c/
+	//           ├── .no-scan
+	//           ├── secret.txt
+	//           ├── target.txt
+	//           └── d/
+	//               └── target.txt
+	tempDir := createTestTree(t,
+		"target.txt",
+		"a/target.txt",
+		"b/c/.no-scan",
+		"b/c/secret.txt",
+		"b/c/target.txt",
+		"b/c/d/target.txt",
+	)
+
+	t.Run("partial results", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, Strategy: DepthFirst, AbortOnFile: ".no-scan"}
+		results, err := FindDownMultiple("target.txt", options)
+		if !errors.Is(err, ErrAborted) {
+			t.Fatalf("Expected ErrAborted, got %v", err)
+		}
+		expected := []string{filepath.Join(tempDir, "target.txt"), filepath.Join(tempDir, "a", "target.txt")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("find down", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, AbortOnFile: ".no-scan"}
+		result, err := FindDown("secret.txt", options)
+		if !errors.Is(err, ErrAborted) {
+			t.Fatalf("Expected ErrAborted, got %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, DownConcurrency: 4, AbortOnFile: ".no-scan"}
+		results, err := FindDownMultiple("target.txt", options)
+		if !errors.Is(err, ErrAborted) {
+			t.Fatalf("Expected ErrAborted, got %v", err)
+		}
+		for _, result := range results {
+			if strings.HasPrefix(result, filepath.Join(tempDir, "b")) {
+				t.Errorf("Expected no matches below the tripwire, got %s", result)
+			}
+		}
+	})
+
+	t.Run("no tripwire", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, AbortOnFile: ".skip-me"}
+		results, err := FindDownMultiple("target.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 4 {
+			t.Errorf("Expected 4 matches, got %v", results)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package findup
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotGitRepo is returned when GitTrackedOnly is set outside a git repository
+var ErrNotGitRepo = errors.New("findup: not inside a git repository")
+
+// GitFileStatus represents the git status of a matched path
+type GitFileStatus int
+
+const (
+	// GitStatusUnknown means the status was not requested or the path is outside a git repository
+	GitStatusUnknown GitFileStatus = iota
+	// GitTracked means the path is tracked and unmodified
+	GitTracked
+	// GitModified means the path is tracked and has staged or unstaged changes
+	GitModified
+	// GitUntracked means the path is not tracked by git
+	GitUntracked
+	// GitIgnored means the path is ignored by git
+	GitIgnored
+)
+
+// gitStatusOf returns the git status of path, or GitStatusUnknown outside a git repository
+func gitStatusOf(path string) (GitFileStatus, error) {
+	dir, base := filepath.Dir(path), filepath.Base(path)
+	if err := exec.Command("git", "-C", dir, "rev-parse", "--git-dir").Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return GitStatusUnknown, err
+		}
+		return GitStatusUnknown, nil
+	}
+
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain", "-z", "--ignored", "--", base).Output()
+	if err != nil {
+		return GitStatusUnknown, err
+	}
+
+	// Each record starts with a two-letter code, only the first one is needed
+	if len(out) >= 2 {
+		switch string(out[:2]) {
+		case "??":
+			return GitUntracked, nil
+		case "!!":
+			return GitIgnored, nil
+		default:
+			return GitModified, nil
+		}
+	}
+
+	// No status output means the path is either clean or unknown to git
+	out, err = exec.Command("git", "-C", dir, "ls-files", "-z", "--", base).Output()
+	if err != nil {
+		return GitStatusUnknown, err
+	}
+	if len(out) == 0 {
+		return GitStatusUnknown, nil
+	}
+	return GitTracked, nil
+}
+
+// gitTrackedPaths returns the files tracked by the git repository containing dir,
+// along with every directory that contains a tracked file
+func gitTrackedPaths(dir string) (map[string]struct{}, error) {
+	cdup, err := exec.Command("git", "-C", dir, "rev-parse", "--show-cdup").Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, err
+		}
+		return nil, ErrNotGitRepo
+	}
+
+	// Resolve the repository root from dir so the paths keep the same form as dir
+	root := filepath.Join(dir, strings.TrimSpace(string(cdup)))
+
+	out, err := exec.Command("git", "-C", root, "ls-files", "-z").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := map[string]struct{}{root: {}}
+	for _, file := range bytes.Split(out, []byte{0}) {
+		if len(file) == 0 {
+			continue
+		}
+
+		// Record the file and each of its parent directories up to the root
+		for current := filepath.Join(root, filepath.FromSlash(string(file))); current != root; current = filepath.Dir(current) {
+			if _, ok := tracked[current]; ok {
+				break
+			}
+			tracked[current] = struct{}{}
+		}
+	}
+
+	return tracked, nil
+}
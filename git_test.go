@@ -0,0 +1,169 @@
+package findup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitTrackedOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// tempDir/
+	//   ├── main.go        (tracked)
+	//   ├── generated.go   (untracked)
+	//   └── pkg/
+	//       ├── lib.go     (tracked)
+	//       └── build/
+	//           └── out.go (untracked)
+	tempDir := createTestTree(t,
+		"main.go",
+		"generated.go",
+		"pkg/lib.go",
+		"pkg/build/out.go",
+	)
+
+	outsideDir := createTestTree(t, "main.go")
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "main.go", "pkg/lib.go"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	t.Run("FindDownMultiple with tracked files only", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, GitTrackedOnly: true}
+		results, err := FindDownMultiple("*.go", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join(tempDir, "main.go"),
+			filepath.Join(tempDir, "pkg", "lib.go"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindUp with tracked directories only", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "pkg", "build"), Type: DirectoryType, GitTrackedOnly: true}
+		result, err := FindUp("build", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected untracked directory to be skipped, got %s", result)
+		}
+	})
+
+	t.Run("GitTrackedOnly outside a repository", func(t *testing.T) {
+		options := &Options{Cwd: outsideDir, GitTrackedOnly: true}
+		if _, err := FindDownMultiple("*.go", options); err != ErrNotGitRepo {
+			t.Errorf("Expected ErrNotGitRepo, got %v", err)
+		}
+
+		options.AllowOutsideGitRepo = true
+		results, err := FindDownMultiple("*.go", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result, got %v", results)
+		}
+	})
+}
+
+func TestGitStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// tempDir/
+	//   ├── .gitignore    (ignores *.log)
+	//   ├── clean.txt     (committed)
+	//   ├── modified.txt  (committed, then changed)
+	//   ├── untracked.txt
+	//   ├── debug.log     (ignored)
+	//   └── sub/
+	tempDir := createTestTree(t,
+		"clean.txt",
+		"modified.txt",
+		"untracked.txt",
+		"debug.log",
+		"sub/",
+	)
+	if err := os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", ".gitignore", "clean.txt", "modified.txt"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "modified.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected GitFileStatus
+	}{
+		{"clean.txt", GitTracked},
+		{"modified.txt", GitModified},
+		{"untracked.txt", GitUntracked},
+		{"debug.log", GitIgnored},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{Cwd: filepath.Join(tempDir, "sub"), GitStatus: true}
+			match, err := FindUpAny([]string{tt.name}, options)
+			if err != nil {
+				t.Fatalf("FindUpAny failed: %v", err)
+			}
+			if match.GitStatus != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, match.GitStatus)
+			}
+		})
+	}
+
+	t.Run("GitStatus not requested", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "sub")}
+		match, err := FindUpAny([]string{"modified.txt"}, options)
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		if match.GitStatus != GitStatusUnknown {
+			t.Errorf("Expected GitStatusUnknown, got %d", match.GitStatus)
+		}
+	})
+
+	t.Run("GitStatus outside a repository", func(t *testing.T) {
+		outsideDir := createTestTree(t, "file.txt")
+		options := &Options{Cwd: outsideDir, GitStatus: true}
+		match, err := FindUpAny([]string{"file.txt"}, options)
+		if err != nil {
+			t.Fatalf("FindUpAny failed: %v", err)
+		}
+		if match.GitStatus != GitStatusUnknown {
+			t.Errorf("Expected GitStatusUnknown, got %d", match.GitStatus)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package findup
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// gitignore evaluates the .gitignore files between a repository root and the paths below it the way
+// git does, caching the parsed files and the directories found to be ignored
+type gitignore struct {
+	root  string
+	mu    sync.Mutex
+	rules map[string][]ignoreRule
+	dirs  map[string]bool
+}
+
+// ignoreRule is a parsed line of a .gitignore file
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// newGitignore returns a gitignore rooted at the nearest directory containing .git at or above cwd,
+// or at cwd itself outside a git repository
+func newGitignore(cwd string) *gitignore {
+	root := cwd
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			root = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return &gitignore{root: root, rules: map[string][]ignoreRule{}, dirs: map[string]bool{}}
+}
+
+// ignored reports whether path is ignored. As git never looks inside an ignored directory, a path
+// below one is ignored even when a negated pattern such as "!important.log" matches it.
+func (g *gitignore) ignored(path string, isDir bool) bool {
+	rel, err := filepath.Rel(g.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	if parent := filepath.Dir(path); parent != g.root && g.ignoredDir(parent) {
+		return true
+	}
+	return g.matches(path, isDir)
+}
+
+// ignoredDir reports whether the directory dir is ignored, caching the answer for its descendants
+func (g *gitignore) ignoredDir(dir string) bool {
+	g.mu.Lock()
+	ignored, ok := g.dirs[dir]
+	g.mu.Unlock()
+	if ok {
+		return ignored
+	}
+
+	ignored = g.ignored(dir, true)
+	g.mu.Lock()
+	g.dirs[dir] = ignored
+	g.mu.Unlock()
+	return ignored
+}
+
+// matches applies the rules of the .gitignore files from the root down to the directory of path,
+// where the last matching rule decides, so deeper files override shallower ones
+func (g *gitignore) matches(path string, isDir bool) bool {
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == g.root || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(dirs[i], path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range g.rulesIn(dirs[i]) {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(rel) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// rulesIn returns the rules of the .gitignore file in dir, or nil when there is none
+func (g *gitignore) rulesIn(dir string) []ignoreRule {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if rules, ok := g.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	if file, err := os.Open(filepath.Join(dir, ".gitignore")); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		file.Close()
+	}
+	g.rules[dir] = rules
+	return rules
+}
+
+// parseIgnoreRule parses a line of a .gitignore file, reporting false for blank lines, comments
+// and invalid patterns, which git skips as well
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	var rule ignoreRule
+
+	// Trailing spaces are dropped unless escaped with a backslash
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || line[0] == '#' {
+		return rule, false
+	}
+
+	if line[0] == '!' {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// A slash other than a trailing one anchors the pattern to the directory of the .gitignore file,
+	// otherwise it matches the name at any level below it
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule, false
+	}
+
+	expr := ignoreGlobToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return rule, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// ignoreGlobToRegexp converts a gitignore glob to a regular expression, where "*" and "?" do not
+// match "/" and "**" matches any number of directories
+func ignoreGlobToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "**" && i > 0 && pattern[i-1] == '/':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '[' && strings.IndexByte(pattern[i+1:], ']') > 0:
+			end := i + 1 + strings.IndexByte(pattern[i+1:], ']')
+			class := pattern[i+1 : end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package findup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRespectGitignore(t *testing.T) {
+	// tempDir/
+	//   ├── .git/
+	//   ├── .gitignore       (*.log, !important.log, build/, !build/keep.log, dist/*, !dist/keep.log)
+	//   ├── debug.log        (ignored)
+	//   ├── important.log    (re-included)
+	//   ├── build/           (ignored, so keep.log cannot be re-included)
+	//   │   └── keep.log
+	//   ├── dist/            (only its contents are ignored)
+	//   │   ├── bundle.log
+	//   │   └── keep.log     (re-included)
+	//   └── sub/
+	//       ├── .gitignore   (!trace.log)
+	//       ├── important.log
+	//       └── trace.log    (re-included)
+	tempDir := createTestTree(t,
+		".git/",
+		"debug.log",
+		"important.log",
+		"build/keep.log",
+		"dist/bundle.log",
+		"dist/keep.log",
+		"sub/important.log",
+		"sub/trace.log",
+	)
+	ignoreFiles := map[string]string{
+		".gitignore":     "# logs\n*.log\n!important.log\n\nbuild/\n!build/keep.log\ndist/*\n!dist/keep.log\n",
+		"sub/.gitignore": "!trace.log\n",
+	}
+	for name, content := range ignoreFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "important.log"),
+		filepath.Join(tempDir, "dist", "keep.log"),
+		filepath.Join(tempDir, "sub", "important.log"),
+		filepath.Join(tempDir, "sub", "trace.log"),
+	}
+
+	t.Run("negation", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1, RespectGitignore: true}
+		results, err := FindDownMultiple("*.log", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("negation inside ignored directory", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "build"), StopAt: filepath.Dir(tempDir), RespectGitignore: true}
+		result, err := FindUp("keep.log", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		options := &Options{Cwd: tempDir, Depth: -1}
+		results, err := FindDownMultiple("*.log", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 7 {
+			t.Errorf("Expected 7 matches, got %v", results)
+		}
+	})
+
+	t.Run("agrees with git", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not installed")
+		}
+		if out, err := exec.Command("git", "-C", tempDir, "init", "-q").CombinedOutput(); err != nil {
+			t.Fatalf("git init failed: %v\n%s", err, out)
+		}
+
+		out, err := exec.Command("git", "-C", tempDir, "ls-files", "--others", "--exclude-standard", "--", "*.log").Output()
+		if err != nil {
+			t.Fatalf("git ls-files failed: %v", err)
+		}
+		var fromGit []string
+		for _, file := range strings.Fields(string(out)) {
+			fromGit = append(fromGit, filepath.Join(tempDir, filepath.FromSlash(file)))
+		}
+		sorted := append([]string(nil), expected...)
+		sort.Strings(sorted)
+		if !reflect.DeepEqual(fromGit, sorted) {
+			t.Errorf("Expected git to report %v, got %v", sorted, fromGit)
+		}
+	})
+}
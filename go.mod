@@ -1,3 +1,10 @@
 module github.com/viguza/find-up
 
 go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	golang.org/x/sys v0.25.0
+	golang.org/x/text v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
+)
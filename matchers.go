@@ -0,0 +1,143 @@
+package findup
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ProjectMatcher returns a matcher that matches a directory only when it contains
+// all of requiredFiles as files and all of requiredDirs as directories
+func ProjectMatcher(requiredFiles []string, requiredDirs []string) MatcherFunc {
+	return func(directory string) (string, bool, error) {
+		for _, name := range requiredFiles {
+			info, err := os.Stat(filepath.Join(directory, name))
+			if err != nil || info.IsDir() {
+				return "", false, nil
+			}
+		}
+
+		for _, name := range requiredDirs {
+			info, err := os.Stat(filepath.Join(directory, name))
+			if err != nil || !info.IsDir() {
+				return "", false, nil
+			}
+		}
+
+		return directory, true, nil
+	}
+}
+
+// WritableDir returns a matcher that matches the first directory the current user can write to.
+// Writability is checked by creating and removing a temporary file, which works on every platform.
+func WritableDir() MatcherFunc {
+	return func(directory string) (string, bool, error) {
+		file, err := os.CreateTemp(directory, ".findup-write-*")
+		if err != nil {
+			return "", false, nil
+		}
+
+		name := file.Name()
+		file.Close()
+		if err := os.Remove(name); err != nil {
+			return "", false, err
+		}
+
+		return directory, true, nil
+	}
+}
+
+// ContainsAll returns a matcher that matches a directory only when, for every pattern,
+// at least one of its entries matches that glob pattern. The directory is read once.
+func ContainsAll(patterns []string) MatcherFunc {
+	return func(directory string) (string, bool, error) {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return "", false, nil
+		}
+
+		for _, pattern := range patterns {
+			found := false
+			for _, entry := range entries {
+				matched, err := matchesGlob(entry.Name(), pattern)
+				if err != nil {
+					return "", false, err
+				}
+				if matched {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return "", false, nil
+			}
+		}
+
+		return directory, true, nil
+	}
+}
+
+// ContainsAtLeast returns a matcher that matches a directory containing at least n files
+// whose names match the glob pattern, e.g. a tests directory with 3 or more "*.test.js" files
+func ContainsAtLeast(pattern string, n int) MatcherFunc {
+	return func(directory string) (string, bool, error) {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return "", false, nil
+		}
+
+		count := 0
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			matched, err := matchesGlob(entry.Name(), pattern)
+			if err != nil {
+				return "", false, err
+			}
+			if matched {
+				count++
+			}
+		}
+
+		if count < n {
+			return "", false, nil
+		}
+		return directory, true, nil
+	}
+}
+
+// LacksFile returns a matcher that matches a directory that does not contain name, e.g. the nearest
+// directory above a Go module for "go.mod". Errors other than name not existing do not match.
+func LacksFile(name string) MatcherFunc {
+	return func(directory string) (string, bool, error) {
+		if _, err := os.Lstat(filepath.Join(directory, name)); !os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return directory, true, nil
+	}
+}
+
+// IsMountPoint returns a matcher that matches a directory that is a filesystem mount point, i.e. one
+// on a different device than its parent, or the filesystem root. With FindUpWithMatcher it finds the
+// nearest mount boundary above Cwd. On platforms without device IDs only the filesystem root matches.
+func IsMountPoint() MatcherFunc {
+	return func(directory string) (string, bool, error) {
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return directory, true, nil
+		}
+
+		// Matchers are not given the search options, so the calls are neither traced nor retried
+		options := &Options{}
+		device, ok := deviceID(options, directory)
+		if !ok {
+			return "", false, nil
+		}
+		parentDevice, ok := deviceID(options, parent)
+		if !ok || parentDevice == device {
+			return "", false, nil
+		}
+
+		return directory, true, nil
+	}
+}
@@ -0,0 +1,229 @@
+package findup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectMatcher(t *testing.T) {
+	// tempDir/
+	//   ├── go.mod
+	//   ├── cmd/
+	//   └── service/
+	//       ├── go.mod
+	//       └── internal/
+	tempDir := createTestTree(t,
+		"go.mod",
+		"cmd/",
+		"service/go.mod",
+		"service/internal/",
+	)
+	internal := filepath.Join(tempDir, "service", "internal")
+	matcher := ProjectMatcher([]string{"go.mod"}, []string{"cmd"})
+
+	t.Run("ProjectMatcher skips partial matches", func(t *testing.T) {
+		result, err := FindUpWithMatcher(matcher, &Options{Cwd: internal})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != tempDir {
+			t.Errorf("Expected %s, got %s", tempDir, result)
+		}
+	})
+
+	t.Run("ProjectMatcher with a file where a directory is required", func(t *testing.T) {
+		matcher := ProjectMatcher(nil, []string{"go.mod"})
+		result, _, err := matcher(tempDir)
+		if err != nil {
+			t.Fatalf("Matcher failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("ProjectMatcher without a full match", func(t *testing.T) {
+		matcher := ProjectMatcher([]string{"go.mod", "go.sum"}, []string{"cmd"})
+		result, err := FindUpWithMatcher(matcher, &Options{Cwd: internal, StopAt: filepath.Dir(tempDir)})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}
+
+func TestWritableDir(t *testing.T) {
+	// tempDir/          (writable)
+	//   └── readonly/   (read-only)
+	//       └── inner/  (read-only)
+	tempDir := createTestTree(t, "readonly/inner/")
+	readonly := filepath.Join(tempDir, "readonly")
+	inner := filepath.Join(readonly, "inner")
+
+	for _, dir := range []string{inner, readonly} {
+		if err := os.Chmod(dir, 0555); err != nil {
+			t.Fatalf("Failed to make %s read-only: %v", dir, err)
+		}
+	}
+	t.Cleanup(func() {
+		os.Chmod(readonly, 0755)
+		os.Chmod(inner, 0755)
+	})
+
+	// Privileged users can write to read-only directories
+	if file, err := os.CreateTemp(inner, "probe-*"); err == nil {
+		file.Close()
+		os.Remove(file.Name())
+		t.Skip("Read-only permissions are not enforced for the current user")
+	}
+
+	result, err := FindUpWithMatcher(WritableDir(), &Options{Cwd: inner})
+	if err != nil {
+		t.Fatalf("FindUpWithMatcher failed: %v", err)
+	}
+	if result != tempDir {
+		t.Errorf("Expected %s, got %s", tempDir, result)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", tempDir, err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the probe file to be removed, got %d entries", len(entries))
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	// tempDir/
+	//   ├── App.sln
+	//   ├── App.csproj
+	//   └── src/
+	//       ├── Lib.csproj
+	//       └── nested/
+	tempDir := createTestTree(t,
+		"App.sln",
+		"App.csproj",
+		"src/Lib.csproj",
+		"src/nested/",
+	)
+	nested := filepath.Join(tempDir, "src", "nested")
+	matcher := ContainsAll([]string{"*.sln", "*.csproj"})
+
+	t.Run("ContainsAll skips partial matches", func(t *testing.T) {
+		result, err := FindUpWithMatcher(matcher, &Options{Cwd: nested})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != tempDir {
+			t.Errorf("Expected %s, got %s", tempDir, result)
+		}
+	})
+
+	t.Run("ContainsAll with only some patterns satisfied", func(t *testing.T) {
+		result, _, err := matcher(filepath.Join(tempDir, "src"))
+		if err != nil {
+			t.Fatalf("Matcher failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+
+	t.Run("ContainsAll with invalid pattern", func(t *testing.T) {
+		_, _, err := ContainsAll([]string{"["})(tempDir)
+		if err == nil {
+			t.Error("Expected error for invalid pattern")
+		}
+	})
+}
+
+func TestContainsAtLeast(t *testing.T) {
+	// tempDir/
+	//   ├── a.test.js
+	//   ├── b.test.js
+	//   ├── c.test.js
+	//   └── src/
+	//       ├── d.test.js
+	//       ├── e.test.js
+	//       ├── f.test.js/
+	//       └── nested/
+	tempDir := createTestTree(t,
+		"a.test.js",
+		"b.test.js",
+		"c.test.js",
+		"src/d.test.js",
+		"src/e.test.js",
+		"src/f.test.js/",
+		"src/nested/",
+	)
+	nested := filepath.Join(tempDir, "src", "nested")
+
+	t.Run("ContainsAtLeast skips directories below the threshold", func(t *testing.T) {
+		result, err := FindUpWithMatcher(ContainsAtLeast("*.test.js", 3), &Options{Cwd: nested})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != tempDir {
+			t.Errorf("Expected %s, got %s", tempDir, result)
+		}
+	})
+
+	t.Run("ContainsAtLeast with a lower threshold", func(t *testing.T) {
+		result, err := FindUpWithMatcher(ContainsAtLeast("*.test.js", 2), &Options{Cwd: nested})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "src")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("ContainsAtLeast with an invalid pattern", func(t *testing.T) {
+		if _, _, err := ContainsAtLeast("[", 1)(tempDir); err == nil {
+			t.Error("Expected an error for an invalid pattern")
+		}
+	})
+}
+
+func TestLacksFile(t *testing.T) {
+	// tempDir/
+	//   └── workspace/
+	//       └── module/
+	//           ├── go.mod
+	//           └── pkg/
+	//               ├── go.mod
+	//               └── internal/
+	tempDir := createTestTree(t,
+		"workspace/module/go.mod",
+		"workspace/module/pkg/go.mod",
+		"workspace/module/pkg/internal/",
+	)
+	internal := filepath.Join(tempDir, "workspace", "module", "pkg", "internal")
+
+	t.Run("LacksFile matches Cwd without the file", func(t *testing.T) {
+		result, err := FindUpWithMatcher(LacksFile("go.mod"), &Options{Cwd: internal})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != internal {
+			t.Errorf("Expected %s, got %s", internal, result)
+		}
+	})
+
+	t.Run("LacksFile finds the first ancestor without the file", func(t *testing.T) {
+		cwd := filepath.Join(tempDir, "workspace", "module", "pkg")
+		result, err := FindUpWithMatcher(LacksFile("go.mod"), &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "workspace")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
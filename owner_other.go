@@ -0,0 +1,10 @@
+//go:build !unix
+
+package findup
+
+import "os"
+
+// ownerMatches always matches on platforms without Unix ownership
+func ownerMatches(info os.FileInfo, uid, gid *int) bool {
+	return true
+}
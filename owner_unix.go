@@ -0,0 +1,24 @@
+//go:build unix
+
+package findup
+
+import (
+	"os"
+	"syscall"
+)
+
+// ownerMatches reports whether info is owned by uid and gid, each of which is ignored when nil
+func ownerMatches(info os.FileInfo, uid, gid *int) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+
+	if uid != nil && int(stat.Uid) != *uid {
+		return false
+	}
+	if gid != nil && int(stat.Gid) != *gid {
+		return false
+	}
+	return true
+}
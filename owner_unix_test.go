@@ -0,0 +1,40 @@
+//go:build unix
+
+package findup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOwner(t *testing.T) {
+	// tempDir/
+	//   ├── owned.txt
+	//   └── dir1/
+	tempDir := createTestTree(t, "owned.txt", "dir1/")
+	cwd := filepath.Join(tempDir, "dir1")
+	uid, gid := os.Getuid(), os.Getgid()
+	otherUID := uid + 1
+
+	t.Run("Uid matching the current user", func(t *testing.T) {
+		result, err := FindUp("owned.txt", &Options{Cwd: cwd, Uid: &uid, Gid: &gid})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "owned.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("Uid matching another user", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, Uid: &otherUID})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no matches, got %v", results)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package findup
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideBase is returned when Cwd is outside the Base directory
+var ErrOutsideBase = errors.New("findup: path is outside base")
+
+// isWithin reports whether path is dir or one of its descendants
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// confineToBase validates Cwd against Base and clamps StopAt so upward searches end at Base
+func confineToBase(opts *Options) error {
+	base, err := filepath.Abs(opts.Base)
+	if err != nil {
+		return err
+	}
+	opts.Base = base
+
+	if !isWithin(opts.Cwd, base) {
+		return ErrOutsideBase
+	}
+
+	opts.resolvedBase, err = filepath.EvalSymlinks(base)
+	if err != nil {
+		return err
+	}
+
+	// Keep a StopAt inside the base, otherwise stop right after searching the base
+	if opts.StopAt == "" || !isWithin(opts.StopAt, base) {
+		opts.StopAt = ""
+		if parent := filepath.Dir(base); parent != base {
+			opts.StopAt = parent
+		}
+	}
+
+	return nil
+}
+
+// insideBase reports whether path, and the file it refers to, are inside the base
+func insideBase(path string, options *Options) bool {
+	if !isWithin(path, options.Base) {
+		return false
+	}
+
+	// Reject symbolic links pointing outside the base, and paths that cannot be resolved such as
+	// dangling links, as where they point cannot be checked
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	return isWithin(resolved, options.resolvedBase)
+}
+
+// CommonAncestor returns the deepest directory containing every one of paths, e.g. the directory of a
+// single file path. It only looks at the paths themselves, without touching the filesystem, and returns
+// an empty string when paths is empty or the paths share no directory, such as paths on different drives.
+func CommonAncestor(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	common := filepath.Dir(filepath.Clean(paths[0]))
+	for _, path := range paths[1:] {
+		dir := filepath.Dir(filepath.Clean(path))
+		for !isWithin(dir, common) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				return ""
+			}
+			common = parent
+		}
+	}
+
+	return common
+}
+
+// AncestorsOf returns the directories containing path, nearest first, from filepath.Dir(path) up to
+// the filesystem root. When stopAt is set the list ends at it, which is left out as in the find functions
+// unless includeStopAt is set. It only looks at the path, without I/O.
+func AncestorsOf(path string, stopAt string, includeStopAt bool) []string {
+	if stopAt != "" {
+		stopAt = filepath.Clean(stopAt)
+	}
+
+	var ancestors []string
+	current := filepath.Dir(filepath.Clean(path))
+	for {
+		if current == stopAt {
+			if includeStopAt {
+				ancestors = append(ancestors, current)
+			}
+			break
+		}
+		ancestors = append(ancestors, current)
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return ancestors
+}
+
+// depthBelow returns the number of directories from dir up to ancestor, or 0 when dir is not below it
+func depthBelow(dir, ancestor string) int {
+	rel, err := filepath.Rel(ancestor, dir)
+	if err != nil || rel == "." || !isWithin(dir, ancestor) {
+		return 0
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// formatPath converts a result path to the form requested by options
+func formatPath(path string, options *Options) string {
+	if path == "" {
+		return path
+	}
+
+	if options.Base != "" {
+		if rel, err := filepath.Rel(options.Base, path); err == nil {
+			path = rel
+		}
+	} else if options.relativeTo != "" {
+		if rel, err := filepath.Rel(options.relativeTo, path); err == nil {
+			path = rel
+		}
+	}
+
+	if options.ForwardSlashes {
+		path = filepath.ToSlash(path)
+	}
+
+	return path
+}
+
+// formatPaths converts result paths in place to the form requested by options
+func formatPaths(paths []string, options *Options) []string {
+	for i, path := range paths {
+		paths[i] = formatPath(path, options)
+	}
+	return paths
+}
@@ -0,0 +1,332 @@
+package findup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBase(t *testing.T) {
+	// tempDir/
+	//   ├── secret.txt
+	//   ├── other/
+	//   └── base/
+	//       ├── config.json
+	//       ├── link.txt -> ../secret.txt
+	//       ├── dangling.txt -> ../missing.txt
+	//       └── app/
+	//           └── src/
+	//               └── config.json
+	tempDir := createTestTree(t,
+		"secret.txt",
+		"other/",
+		"base/config.json",
+		"base/app/src/config.json",
+	)
+	base := filepath.Join(tempDir, "base")
+	src := filepath.Join(base, "app", "src")
+	symlinks := os.Symlink(filepath.Join(tempDir, "secret.txt"), filepath.Join(base, "link.txt")) == nil &&
+		os.Symlink(filepath.Join(tempDir, "missing.txt"), filepath.Join(base, "dangling.txt")) == nil
+
+	t.Run("Cwd outside base is rejected", func(t *testing.T) {
+		for _, cwd := range []string{
+			filepath.Join(tempDir, "other"),
+			filepath.Join(base, "..", "other"),
+			filepath.Join(src, "..", "..", ".."),
+		} {
+			if _, err := FindUp("secret.txt", &Options{Cwd: cwd, Base: base}); err != ErrOutsideBase {
+				t.Errorf("Expected ErrOutsideBase for %s, got %v", cwd, err)
+			}
+		}
+	})
+
+	t.Run("FindUp does not leave the base", func(t *testing.T) {
+		result, err := FindUp("secret.txt", &Options{Cwd: src, Base: base})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindUp with escaping name", func(t *testing.T) {
+		result, err := FindUp(filepath.Join("..", "secret.txt"), &Options{Cwd: base, Base: base})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindUp with symlink escaping the base", func(t *testing.T) {
+		if !symlinks {
+			t.Skip("Symlinks not supported")
+		}
+		result, err := FindUp("link.txt", &Options{Cwd: src, Base: base, AllowSymlinks: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindUp with dangling symlink escaping the base", func(t *testing.T) {
+		if !symlinks {
+			t.Skip("Symlinks not supported")
+		}
+		options := &Options{Cwd: src, Base: base, Type: SymlinkType, AllowSymlinks: true}
+		result, err := FindUp("dangling.txt", options)
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+
+	t.Run("FindUpMultiple searches the base itself", func(t *testing.T) {
+		results, err := FindUpMultiple("config.json", &Options{Cwd: src, Base: base, StopAt: tempDir})
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		expected := []string{
+			filepath.Join("app", "src", "config.json"),
+			"config.json",
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownMultiple returns paths relative to the base", func(t *testing.T) {
+		results, err := FindDownMultiple("config.json", &Options{Cwd: base, Base: base, Depth: -1})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{
+			"config.json",
+			filepath.Join("app", "src", "config.json"),
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+}
+
+func TestForwardSlashes(t *testing.T) {
+	// tempDir/
+	//   ├── config.json
+	//   └── dir1/
+	//       └── dir2/
+	//           └── main.go
+	tempDir := createTestTree(t, "config.json", "dir1/dir2/main.go")
+	cwd := filepath.Join(tempDir, "dir1", "dir2")
+
+	t.Run("ForwardSlashes with FindUp", func(t *testing.T) {
+		result, err := FindUp("config.json", &Options{Cwd: cwd, ForwardSlashes: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.ToSlash(filepath.Join(tempDir, "config.json"))
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("ForwardSlashes with FindDownMultiple", func(t *testing.T) {
+		results, err := FindDownMultiple("*.go", &Options{Cwd: tempDir, Depth: -1, ForwardSlashes: true})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.ToSlash(filepath.Join(cwd, "main.go"))}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("ForwardSlashes on Windows", func(t *testing.T) {
+		if runtime.GOOS != "windows" {
+			t.Skip("backslash separators only occur on Windows")
+		}
+
+		result, err := FindUp("config.json", &Options{Cwd: cwd, ForwardSlashes: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result == "" || strings.Contains(result, `\`) {
+			t.Errorf("Expected a path with forward slashes, got %s", result)
+		}
+	})
+}
+
+func TestCommonAncestor(t *testing.T) {
+	root := string(filepath.Separator)
+	if runtime.GOOS == "windows" {
+		root = `C:\`
+	}
+	project := filepath.Join(root, "home", "user", "project")
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"single path", []string{filepath.Join(project, "go.mod")}, project},
+		{"siblings", []string{filepath.Join(project, "a.go"), filepath.Join(project, "b.go")}, project},
+		{"different depths", []string{
+			filepath.Join(project, "cmd", "main.go"),
+			filepath.Join(project, "internal", "util", "util.go"),
+			filepath.Join(project, "go.mod"),
+		}, project},
+		{"similar prefixes", []string{
+			filepath.Join(project, "app", "a.go"),
+			filepath.Join(project, "application", "b.go"),
+		}, project},
+		{"only the root in common", []string{
+			filepath.Join(root, "etc", "hosts"),
+			filepath.Join(project, "go.mod"),
+		}, root},
+		{"relative paths", []string{filepath.Join("src", "a", "x.go"), filepath.Join("src", "b", "y.go")}, "src"},
+		{"relative and absolute paths", []string{filepath.Join("src", "x.go"), filepath.Join(project, "go.mod")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := CommonAncestor(tt.paths); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("paths on different drives", func(t *testing.T) {
+		if runtime.GOOS != "windows" {
+			t.Skip("drive letters only exist on Windows")
+		}
+
+		if result := CommonAncestor([]string{`C:\project\a.go`, `D:\project\b.go`}); result != "" {
+			t.Errorf("Expected empty result, got %q", result)
+		}
+	})
+}
+
+func TestAncestorsOf(t *testing.T) {
+	root := string(filepath.Separator)
+	if runtime.GOOS == "windows" {
+		root = `C:\`
+	}
+	project := filepath.Join(root, "home", "user", "project")
+	file := filepath.Join(project, "src", "main.go")
+
+	t.Run("AncestorsOf up to the root", func(t *testing.T) {
+		expected := []string{
+			filepath.Join(project, "src"),
+			project,
+			filepath.Join(root, "home", "user"),
+			filepath.Join(root, "home"),
+			root,
+		}
+		if result := AncestorsOf(file, "", false); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("AncestorsOf stops below stopAt", func(t *testing.T) {
+		expected := []string{filepath.Join(project, "src")}
+		if result := AncestorsOf(file, project+string(filepath.Separator), false); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("AncestorsOf with stopAt as the parent", func(t *testing.T) {
+		if result := AncestorsOf(file, filepath.Join(project, "src"), false); len(result) != 0 {
+			t.Errorf("Expected no ancestors, got %v", result)
+		}
+	})
+
+	t.Run("AncestorsOf with unrelated stopAt", func(t *testing.T) {
+		result := AncestorsOf(file, filepath.Join(root, "other"), true)
+		if len(result) != 5 || result[len(result)-1] != root {
+			t.Errorf("Expected every ancestor up to the root, got %v", result)
+		}
+	})
+
+	t.Run("AncestorsOf including stopAt", func(t *testing.T) {
+		expected := []string{filepath.Join(project, "src"), project}
+		if result := AncestorsOf(file, project, true); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("AncestorsOf including stopAt as the parent", func(t *testing.T) {
+		expected := []string{filepath.Join(project, "src")}
+		if result := AncestorsOf(file, filepath.Join(project, "src"), true); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("AncestorsOf including the root as stopAt", func(t *testing.T) {
+		result := AncestorsOf(file, root, true)
+		if len(result) != 5 || result[len(result)-1] != root {
+			t.Errorf("Expected every ancestor up to the root, got %v", result)
+		}
+	})
+}
+
+func TestKeepRelative(t *testing.T) {
+	// tempDir/
+	//   ├── go.mod
+	//   └── cmd/
+	//       ├── main.go
+	//       └── tool/
+	//           └── tool.go
+	tempDir := createTestTree(t, "go.mod", "cmd/main.go", "cmd/tool/tool.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	if err := os.Chdir(filepath.Join(tempDir, "cmd")); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Run("FindUp with a relative Cwd", func(t *testing.T) {
+		result, err := FindUp("go.mod", &Options{Cwd: ".", KeepRelative: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join("..", "go.mod")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("FindDownMultiple with a relative Cwd", func(t *testing.T) {
+		results, err := FindDownMultiple("*.go", &Options{Cwd: "tool", Depth: -1, KeepRelative: true})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{"tool.go"}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("absolute Cwd stays absolute", func(t *testing.T) {
+		result, err := FindUp("go.mod", &Options{Cwd: filepath.Join(tempDir, "cmd"), KeepRelative: true})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "go.mod")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+}
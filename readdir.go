@@ -0,0 +1,124 @@
+package findup
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// readDirChunk is the number of entries read at once when MaxEntriesPerDir is set
+const readDirChunk = 256
+
+// readDir lists dir in name order with options.ReadDirFunc or os.ReadDir, retrying transient errors.
+// When MaxEntriesPerDir is set only that many entries, in directory order, are read and sorted, or
+// the first ones ReadDirFunc lists are kept.
+func readDir(options *Options, dir string) ([]fs.DirEntry, error) {
+	read, chunked := options.ReadDirFunc, false
+	if read != nil && options.MaxEntriesPerDir > 0 {
+		list := read
+		read = func(dir string) ([]fs.DirEntry, error) {
+			entries, err := list(dir)
+			if len(entries) > options.MaxEntriesPerDir {
+				entries = entries[:options.MaxEntriesPerDir]
+			}
+			return entries, err
+		}
+	}
+	if read == nil {
+		read = os.ReadDir
+		if options.MaxEntriesPerDir > 0 {
+			// scanDir counts the entries it reads
+			chunked = true
+			read = func(dir string) ([]fs.DirEntry, error) {
+				var entries []fs.DirEntry
+				err := scanDir(options, dir, func(chunk []fs.DirEntry) bool {
+					entries = append(entries, chunk...)
+					return true
+				})
+				sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+				return entries, err
+			}
+		}
+	}
+
+	var entries []fs.DirEntry
+	err := traceReadDir(options, dir, func() (err error) {
+		entries, err = withRetry(options, func() ([]fs.DirEntry, error) {
+			return read(dir)
+		})
+		return err
+	})
+	if options.Stats != nil && !chunked {
+		atomic.AddInt64(&options.Stats.EntriesRead, int64(len(entries)))
+	}
+	return entries, err
+}
+
+// scanDir passes the entries of dir to visit in chunks, in directory order, until visit
+// returns false, every entry was read or MaxEntriesPerDir entries were read
+func scanDir(options *Options, dir string, visit func(chunk []fs.DirEntry) bool) error {
+	file, err := withRetry(options, func() (*os.File, error) {
+		return os.Open(dir)
+	})
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	remaining := options.MaxEntriesPerDir
+	for remaining > 0 {
+		chunk, err := file.ReadDir(min(readDirChunk, remaining))
+		if len(chunk) > 0 {
+			remaining -= len(chunk)
+			if options.Stats != nil {
+				atomic.AddInt64(&options.Stats.EntriesRead, int64(len(chunk)))
+			}
+			if !visit(chunk) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// firstMatchingEntry returns the preferred entry of dir among those whose name satisfies match and whose
+// path matches options, in directory order. The directory is read in chunks only until the first one is
+// found, unless PreferRealFiles or BothTypePreference need every match to choose from.
+func firstMatchingEntry(dir string, options *Options, match func(entryName string) bool) (string, error) {
+	choose := options.PreferRealFiles || (options.Type == BothType && options.BothTypePreference != NoPreference)
+
+	var matches []string
+	err := traceReadDir(options, dir, func() error {
+		return scanDir(options, dir, func(chunk []fs.DirEntry) bool {
+			for _, entry := range chunk {
+				if !match(entry.Name()) {
+					continue
+				}
+
+				target := filepath.Join(dir, entry.Name())
+				if ok, err := pathMatches(target, options); err == nil && ok {
+					matches = append(matches, target)
+					if !choose {
+						return false
+					}
+				}
+			}
+			return true
+		})
+	})
+
+	if len(matches) == 0 {
+		return "", err
+	}
+	return preferredMatch(matches, options), err
+}
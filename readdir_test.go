@@ -0,0 +1,115 @@
+package findup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaxEntriesPerDir(t *testing.T) {
+	// tempDir/
+	//   ├── file0000.txt ... file1999.txt
+	//   └── sub/
+	tempDir := createTestTree(t, "sub/")
+	const total = 2000
+	for i := 0; i < total; i++ {
+		if err := os.WriteFile(filepath.Join(tempDir, fmt.Sprintf("file%04d.txt", i)), nil, 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	t.Run("FindDown stops reading at the first match", func(t *testing.T) {
+		stats := &Stats{}
+		result, err := FindDown("*.txt", &Options{Cwd: tempDir, MaxEntriesPerDir: total * 2, Stats: stats})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		if filepath.Dir(result) != tempDir || filepath.Ext(result) != ".txt" {
+			t.Errorf("Expected a text file in %s, got %s", tempDir, result)
+		}
+		if stats.EntriesRead >= total {
+			t.Errorf("Expected early termination, read %d entries", stats.EntriesRead)
+		}
+	})
+
+	t.Run("FindDown without MaxEntriesPerDir reads every entry", func(t *testing.T) {
+		stats := &Stats{}
+		result, err := FindDown("*.txt", &Options{Cwd: tempDir, Stats: stats})
+		if err != nil {
+			t.Fatalf("FindDown failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "file0000.txt")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+		if stats.EntriesRead != total+1 {
+			t.Errorf("Expected %d entries read, got %d", total+1, stats.EntriesRead)
+		}
+	})
+
+	t.Run("FindDownMultiple stops at MaxEntriesPerDir", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: -1, MaxEntriesPerDir: 100})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) > 100 {
+			t.Errorf("Expected at most 100 results, got %d", len(results))
+		}
+	})
+
+	t.Run("MaxEntriesPerDir caps ReadDirFunc", func(t *testing.T) {
+		calls := 0
+		options := &Options{
+			Cwd:              tempDir,
+			StopAt:           filepath.Dir(tempDir),
+			MaxEntriesPerDir: 10,
+			ReadDirFunc: func(dir string) ([]fs.DirEntry, error) {
+				calls++
+				return os.ReadDir(dir)
+			},
+		}
+		results, err := FindUpMultiple("*.txt", options)
+		if err != nil {
+			t.Fatalf("FindUpMultiple failed: %v", err)
+		}
+		if calls == 0 {
+			t.Error("Expected ReadDirFunc to be called")
+		}
+		if len(results) != 10 || results[9] != filepath.Join(tempDir, "file0009.txt") {
+			t.Errorf("Expected the first 10 files, got %d results", len(results))
+		}
+
+		calls = 0
+		if _, err := FindUp("*.txt", options); err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if calls == 0 {
+			t.Error("Expected ReadDirFunc to be called by FindUp")
+		}
+	})
+
+	t.Run("MaxEntriesPerDir keeps BothTypePreference", func(t *testing.T) {
+		// tempDir/
+		//   ├── config/
+		//   └── config.json
+		prefDir := createTestTree(t, "config/", "config.json")
+		for _, tt := range []struct {
+			preference TypePreference
+			expected   string
+		}{
+			{FilesFirst, "config.json"},
+			{DirsFirst, "config"},
+		} {
+			options := &Options{Cwd: prefDir, Type: BothType, BothTypePreference: tt.preference, MaxEntriesPerDir: 10}
+			result, err := FindUp("config*", options)
+			if err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+			if expected := filepath.Join(prefDir, tt.expected); result != expected {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package findup
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// RetryPolicy controls how filesystem calls failing with transient errors are retried,
+// e.g. on network filesystems where listing a directory can fail intermittently
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per call including the first (1 or less disables retries)
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled before each further retry
+	Backoff time.Duration
+	// Retryable reports whether err is transient (nil retries every error except missing entries and permission errors)
+	Retryable func(err error) bool
+}
+
+// retryable reports whether err should be retried under policy
+func (policy *RetryPolicy) retryable(err error) bool {
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+	return !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrPermission)
+}
+
+// withRetry calls op until it succeeds, fails with an error that is not transient
+// or runs out of the attempts allowed by options.RetryPolicy
+func withRetry[T any](options *Options, op func() (T, error)) (T, error) {
+	value, err := op()
+
+	policy := options.RetryPolicy
+	if policy == nil {
+		return value, err
+	}
+
+	backoff := policy.Backoff
+	for attempt := 1; err != nil && attempt < policy.MaxAttempts && policy.retryable(err); attempt++ {
+		if backoff > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		value, err = op()
+	}
+
+	return value, err
+}
+
+// lstat returns the file info of path without following symbolic links, retrying transient errors
+func lstat(options *Options, path string) (os.FileInfo, error) {
+	return traceStat(options, path, func() (os.FileInfo, error) {
+		return withRetry(options, func() (os.FileInfo, error) {
+			return os.Lstat(path)
+		})
+	})
+}
+
+// stat returns the file info of path, retrying transient errors
+func stat(options *Options, path string) (os.FileInfo, error) {
+	return traceStat(options, path, func() (os.FileInfo, error) {
+		return withRetry(options, func() (os.FileInfo, error) {
+			return os.Stat(path)
+		})
+	})
+}
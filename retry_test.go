@@ -0,0 +1,80 @@
+package findup
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	// tempDir/
+	//   └── flaky/
+	//       └── file.txt
+	tempDir := createTestTree(t, "flaky/file.txt")
+	flaky := filepath.Join(tempDir, "flaky")
+	errTransient := errors.New("transient failure")
+
+	// flakyReadDir fails the first listing of the flaky directory
+	flakyReadDir := func(calls *int) func(dir string) ([]fs.DirEntry, error) {
+		return func(dir string) ([]fs.DirEntry, error) {
+			if dir == flaky {
+				*calls++
+				if *calls == 1 {
+					return nil, errTransient
+				}
+			}
+			return os.ReadDir(dir)
+		}
+	}
+
+	t.Run("FindDownMultiple recovers from a transient error", func(t *testing.T) {
+		calls := 0
+		results, err := FindDownMultiple("file.txt", &Options{
+			Cwd:         tempDir,
+			Depth:       -1,
+			ReadDirFunc: flakyReadDir(&calls),
+			RetryPolicy: &RetryPolicy{MaxAttempts: 3},
+		})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		expected := []string{filepath.Join(flaky, "file.txt")}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownMultiple without RetryPolicy", func(t *testing.T) {
+		calls := 0
+		_, err := FindDownMultiple("file.txt", &Options{
+			Cwd:         tempDir,
+			Depth:       -1,
+			ReadDirFunc: flakyReadDir(&calls),
+		})
+		if !errors.Is(err, errTransient) {
+			t.Errorf("Expected transient error, got %v", err)
+		}
+	})
+
+	t.Run("RetryPolicy does not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		_, err := FindDownMultiple("file.txt", &Options{
+			Cwd:   tempDir,
+			Depth: -1,
+			ReadDirFunc: func(dir string) ([]fs.DirEntry, error) {
+				calls++
+				return nil, fs.ErrPermission
+			},
+			RetryPolicy: &RetryPolicy{MaxAttempts: 3},
+		})
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Expected permission error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 attempt, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package findup
+
+import "path/filepath"
+
+// RootKind represents the kind of project a root marker identifies
+type RootKind int
+
+const (
+	// UnknownRoot is the kind of markers that do not identify a known kind of project
+	UnknownRoot RootKind = iota
+	// GoModule is a Go module, marked by go.mod
+	GoModule
+	// NodeProject is an npm project, marked by package.json
+	NodeProject
+	// CargoCrate is a Rust crate, marked by Cargo.toml
+	CargoCrate
+	// Git is a git repository, marked by .git
+	Git
+)
+
+// RootMarker is a file or directory name marking the root of a project of the given kind
+type RootMarker struct {
+	Name string
+	Kind RootKind
+}
+
+// DefaultRootMarkers are the markers DetectRoot looks for when RootMarkers is not set, in priority order
+var DefaultRootMarkers = []RootMarker{
+	{Name: "go.mod", Kind: GoModule},
+	{Name: "package.json", Kind: NodeProject},
+	{Name: "Cargo.toml", Kind: CargoCrate},
+	{Name: ".git", Kind: Git},
+}
+
+// RootInfo describes a project root found by DetectRoot
+type RootInfo struct {
+	// Dir is the root directory
+	Dir string
+	// Marker is the name of the marker found in Dir
+	Marker string
+	// Kind is the kind of project the marker identifies
+	Kind RootKind
+}
+
+// DetectRoot finds the nearest project root by walking up parent directories until one contains
+// any of the RootMarkers, or DefaultRootMarkers when it is empty, and reports which marker matched.
+// When a directory contains several markers the first in the list wins. Markers match files and
+// directories alike, whatever Type is set to. It returns nil when no marker is found.
+func DetectRoot(options *Options) (*RootInfo, error) {
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	defer recordElapsed(opts)
+
+	markers := opts.RootMarkers
+	if len(markers) == 0 {
+		markers = DefaultRootMarkers
+	}
+	names := make([]string, len(markers))
+	for i, marker := range markers {
+		names[i] = marker.Name
+	}
+
+	markerOpts := *opts
+	markerOpts.Type = BothType
+	match, err := findUpAnyInDir(opts.Cwd, names, &markerOpts, opts.StopAt)
+	if err != nil || match.Path == "" {
+		return nil, err
+	}
+
+	info := &RootInfo{Dir: formatPath(filepath.Dir(match.Path), opts), Marker: match.MatchedName}
+	for _, marker := range markers {
+		if marker.Name == match.MatchedName {
+			info.Kind = marker.Kind
+			break
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,77 @@
+package findup
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectRoot(t *testing.T) {
+	// tempDir/
+	//   ├── .git/
+	//   ├── goproject/
+	//   │   ├── go.mod
+	//   │   └── internal/
+	//   │       └── util/
+	//   └── webapp/
+	//       ├── package.json
+	//       └── src/
+	tempDir := createTestTree(t,
+		".git/",
+		"goproject/go.mod",
+		"goproject/internal/util/",
+		"webapp/package.json",
+		"webapp/src/",
+	)
+
+	tests := []struct {
+		name   string
+		cwd    string
+		dir    string
+		marker string
+		kind   RootKind
+	}{
+		{"go module", filepath.Join(tempDir, "goproject", "internal", "util"), filepath.Join(tempDir, "goproject"), "go.mod", GoModule},
+		{"npm project", filepath.Join(tempDir, "webapp", "src"), filepath.Join(tempDir, "webapp"), "package.json", NodeProject},
+		{"git repository", tempDir, tempDir, ".git", Git},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := DetectRoot(&Options{Cwd: tt.cwd})
+			if err != nil {
+				t.Fatalf("DetectRoot failed: %v", err)
+			}
+			if info == nil {
+				t.Fatalf("Expected a root in %s, got nil", tt.dir)
+			}
+			if info.Dir != tt.dir || info.Marker != tt.marker || info.Kind != tt.kind {
+				t.Errorf("Expected %s marked by %s (kind %d), got %+v", tt.dir, tt.marker, tt.kind, info)
+			}
+		})
+	}
+
+	t.Run("custom markers", func(t *testing.T) {
+		options := &Options{
+			Cwd:         filepath.Join(tempDir, "webapp", "src"),
+			RootMarkers: []RootMarker{{Name: ".git", Kind: Git}},
+		}
+		info, err := DetectRoot(options)
+		if err != nil {
+			t.Fatalf("DetectRoot failed: %v", err)
+		}
+		if info == nil || info.Dir != tempDir || info.Kind != Git {
+			t.Errorf("Expected %s marked by .git, got %+v", tempDir, info)
+		}
+	})
+
+	t.Run("no root", func(t *testing.T) {
+		options := &Options{Cwd: filepath.Join(tempDir, "webapp", "src"), StopAt: tempDir, RootMarkers: []RootMarker{{Name: "Cargo.toml", Kind: CargoCrate}}}
+		info, err := DetectRoot(options)
+		if err != nil {
+			t.Fatalf("DetectRoot failed: %v", err)
+		}
+		if info != nil {
+			t.Errorf("Expected no root, got %+v", info)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+//go:build go1.23
+
+package findup
+
+import "iter"
+
+// FindDownSeq returns an iterator over the files or directories found by walking down
+// descendant directories. The walk advances lazily and stops as soon as the caller
+// breaks out of the loop. An error ends the sequence after being yielded with an empty path.
+func FindDownSeq(name string, options *Options) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		opts, err := resolveOptions(options)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		defer recordElapsed(opts)
+
+		count := 0
+		stopped := false
+		_, err = walkDown([]walkFrame{{Dir: opts.Cwd, Depth: opts.StartDepth, Root: true}}, name, opts, func(match EntryMatch) (bool, bool) {
+			count++
+			if !yield(formatPath(match.Path, opts), nil) {
+				stopped = true
+				return true, false
+			}
+
+			// Check if we've reached the limit
+			return true, opts.Limit <= 0 || count < opts.Limit
+		})
+		if err != nil && !stopped {
+			yield("", err)
+		}
+	}
+}
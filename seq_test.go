@@ -0,0 +1,65 @@
+//go:build go1.23
+
+package findup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDownSeq(t *testing.T) {
+	// tempDir/
+	//   ├── a.txt
+	//   ├── dir1/
+	//   │   └── b.txt
+	//   └── dir2/
+	//       └── c.txt
+	tempDir := createTestTree(t,
+		"a.txt",
+		"dir1/b.txt",
+		"dir2/c.txt",
+	)
+	options := &Options{Cwd: tempDir, Depth: -1}
+
+	t.Run("FindDownSeq yields all matches", func(t *testing.T) {
+		expected, err := FindDownMultiple("*.txt", options)
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+
+		var results []string
+		for path, err := range FindDownSeq("*.txt", options) {
+			if err != nil {
+				t.Fatalf("FindDownSeq failed: %v", err)
+			}
+			results = append(results, path)
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("Expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("FindDownSeq stops when the loop breaks", func(t *testing.T) {
+		var results []string
+		for path, err := range FindDownSeq("*.txt", options) {
+			if err != nil {
+				t.Fatalf("FindDownSeq failed: %v", err)
+			}
+			results = append(results, path)
+			break
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result, got %v", results)
+		}
+
+		// Drive the iterator directly to confirm no value is yielded after a false return
+		calls := 0
+		FindDownSeq("*.txt", options)(func(path string, err error) bool {
+			calls++
+			return false
+		})
+		if calls != 1 {
+			t.Errorf("Expected the walk to stop after 1 match, got %d calls", calls)
+		}
+	})
+}
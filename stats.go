@@ -0,0 +1,45 @@
+package findup
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats collects statistics about the find calls it is passed to through Options.
+// Values add up across calls sharing the same Stats, so pass a fresh one to measure a single call.
+// Fields are updated atomically and should only be read once the calls have returned.
+type Stats struct {
+	// DirsVisited is the number of directories searched
+	DirsVisited int64
+	// Matches is the number of matches found, including matches later dropped by Seen or MaxResults
+	Matches int64
+	// EntriesRead is the number of directory entries read while listing directories
+	EntriesRead int64
+	// Pruned is the number of directories findDown functions did not descend into
+	Pruned int64
+	// Elapsed is the wall-clock time spent in the find calls, from resolving the options to returning
+	Elapsed time.Duration
+}
+
+// enterDir records that dir at depth is being searched
+func enterDir(options *Options, dir string, depth int) {
+	if options.Stats != nil {
+		atomic.AddInt64(&options.Stats.DirsVisited, 1)
+	}
+	logDebug(options, "entering directory", dir, depth)
+}
+
+// foundMatch records a match at path found at depth
+func foundMatch(options *Options, path string, depth int) {
+	if options.Stats != nil {
+		atomic.AddInt64(&options.Stats.Matches, 1)
+	}
+	logDebug(options, "match", path, depth)
+}
+
+// recordElapsed adds the time since options were resolved to Stats.Elapsed
+func recordElapsed(options *Options) {
+	if options.Stats != nil {
+		atomic.AddInt64((*int64)(&options.Stats.Elapsed), int64(time.Since(options.started)))
+	}
+}
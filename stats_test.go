@@ -0,0 +1,52 @@
+package findup
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	// tempDir/
+	//   ├── a.txt
+	//   ├── dir1/
+	//   │   └── b.txt
+	//   └── dir2/
+	//       └── deep/
+	tempDir := createTestTree(t, "a.txt", "dir1/b.txt", "dir2/deep/")
+
+	t.Run("FindDownMultiple fills Stats", func(t *testing.T) {
+		stats := &Stats{}
+		_, err := FindDownMultiple("*.txt", &Options{Cwd: tempDir, Depth: 1, Stats: stats})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if stats.Elapsed <= 0 {
+			t.Errorf("Expected positive elapsed time, got %v", stats.Elapsed)
+		}
+		if stats.DirsVisited != 3 {
+			t.Errorf("Expected 3 directories visited, got %d", stats.DirsVisited)
+		}
+		if stats.Matches != 2 {
+			t.Errorf("Expected 2 matches, got %d", stats.Matches)
+		}
+		if stats.Pruned != 1 {
+			t.Errorf("Expected 1 pruned directory, got %d", stats.Pruned)
+		}
+	})
+
+	t.Run("Stats add up across calls", func(t *testing.T) {
+		stats := &Stats{}
+		cwd := filepath.Join(tempDir, "dir1")
+		for i := 0; i < 2; i++ {
+			if _, err := FindUp("a.txt", &Options{Cwd: cwd, Stats: stats}); err != nil {
+				t.Fatalf("FindUp failed: %v", err)
+			}
+		}
+		if stats.DirsVisited != 4 || stats.Matches != 2 {
+			t.Errorf("Expected 4 directories and 2 matches, got %d and %d", stats.DirsVisited, stats.Matches)
+		}
+		if stats.Elapsed <= 0 {
+			t.Errorf("Expected positive elapsed time, got %v", stats.Elapsed)
+		}
+	})
+}
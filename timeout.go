@@ -0,0 +1,40 @@
+package findup
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLevelTimeout is passed to OnError when the work for one directory exceeds PerLevelTimeout
+var ErrLevelTimeout = errors.New("findup: directory level timed out")
+
+// withLevelTimeout runs work for one directory level, giving up with ErrLevelTimeout after
+// options.PerLevelTimeout. Work that times out keeps running in the background, so it must
+// not write to state shared with the caller and only hand its result back through the return values.
+func withLevelTimeout[T any](options *Options, work func() (T, error)) (T, error) {
+	if options.PerLevelTimeout <= 0 {
+		return work()
+	}
+
+	type outcome struct {
+		value T
+		err   error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := work()
+		done <- outcome{value: value, err: err}
+	}()
+
+	timer := time.NewTimer(options.PerLevelTimeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-timer.C:
+		var zero T
+		return zero, ErrLevelTimeout
+	}
+}
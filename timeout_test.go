@@ -0,0 +1,123 @@
+package findup
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPerLevelTimeout(t *testing.T) {
+	// tempDir/
+	//   ├── marker
+	//   └── slow/
+	//       └── dir1/
+	tempDir := createTestTree(t, "marker", "slow/dir1/")
+	slow := filepath.Join(tempDir, "slow")
+	cwd := filepath.Join(slow, "dir1")
+
+	// The matcher stalls on the slow directory, which would otherwise match
+	matcher := func(directory string) (string, bool, error) {
+		if directory == slow {
+			time.Sleep(500 * time.Millisecond)
+			return directory, true, nil
+		}
+		if directory == tempDir {
+			return directory, true, nil
+		}
+		return "", false, nil
+	}
+
+	t.Run("PerLevelTimeout skips the slow directory", func(t *testing.T) {
+		var skipped []string
+		start := time.Now()
+		result, err := FindUpWithMatcher(matcher, &Options{
+			Cwd:             cwd,
+			PerLevelTimeout: 50 * time.Millisecond,
+			OnError: func(dir string, err error) error {
+				if !errors.Is(err, ErrLevelTimeout) {
+					t.Errorf("Expected ErrLevelTimeout, got %v", err)
+				}
+				skipped = append(skipped, dir)
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != tempDir {
+			t.Errorf("Expected %s, got %s", tempDir, result)
+		}
+		if len(skipped) != 1 || skipped[0] != slow {
+			t.Errorf("Expected only %s to be skipped, got %v", slow, skipped)
+		}
+		if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+			t.Errorf("Expected the search to finish before the slow matcher, took %v", elapsed)
+		}
+	})
+
+	t.Run("PerLevelTimeout aborts when OnError returns an error", func(t *testing.T) {
+		_, err := FindUpWithMatcher(matcher, &Options{
+			Cwd:             cwd,
+			PerLevelTimeout: 50 * time.Millisecond,
+			OnError: func(dir string, err error) error {
+				return err
+			},
+		})
+		if !errors.Is(err, ErrLevelTimeout) {
+			t.Errorf("Expected ErrLevelTimeout, got %v", err)
+		}
+	})
+
+	t.Run("Without PerLevelTimeout", func(t *testing.T) {
+		result, err := FindUpWithMatcher(matcher, &Options{Cwd: cwd})
+		if err != nil {
+			t.Fatalf("FindUpWithMatcher failed: %v", err)
+		}
+		if result != slow {
+			t.Errorf("Expected %s, got %s", slow, result)
+		}
+	})
+
+	t.Run("PerLevelTimeout with FindUp", func(t *testing.T) {
+		result, err := FindUp("marker", &Options{Cwd: cwd, PerLevelTimeout: time.Second})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "marker")
+		if result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	})
+
+	t.Run("PerLevelTimeout with FindUpAnyMultiple", func(t *testing.T) {
+		// Listing the slow directory outlives its timeout while the walk goes on filling the groups
+		options := &Options{
+			Cwd:             cwd,
+			StopAt:          filepath.Dir(tempDir),
+			PerLevelTimeout: 20 * time.Millisecond,
+			ReadDirFunc: func(dir string) ([]fs.DirEntry, error) {
+				if dir == slow {
+					time.Sleep(100 * time.Millisecond)
+				}
+				return os.ReadDir(dir)
+			},
+			OnError: func(dir string, err error) error {
+				return nil
+			},
+		}
+		groups, err := FindUpAnyMultiple([]string{"mark*", "*"}, options)
+		if err != nil {
+			t.Fatalf("FindUpAnyMultiple failed: %v", err)
+		}
+		expected := filepath.Join(tempDir, "marker")
+		if len(groups[0].Paths) != 1 || groups[0].Paths[0] != expected {
+			t.Errorf("Expected %s, got %v", expected, groups[0].Paths)
+		}
+
+		// Let the timed out lookup finish while the race detector watches
+		time.Sleep(150 * time.Millisecond)
+	})
+}
@@ -0,0 +1,40 @@
+package findup
+
+import (
+	"os"
+	"time"
+)
+
+// Tracer receives the duration and outcome of each directory listing and stat performed by a search,
+// including retries, which helps finding the slowest directories. Its methods may be called concurrently
+// by searches sharing it.
+type Tracer interface {
+	// OnReadDir is called after listing the directory at path
+	OnReadDir(path string, dur time.Duration, err error)
+	// OnStat is called after reading the file info of path
+	OnStat(path string, dur time.Duration, err error)
+}
+
+// traceReadDir runs op listing dir and reports it to options.Tracer
+func traceReadDir(options *Options, dir string, op func() error) error {
+	if options.Tracer == nil {
+		return op()
+	}
+
+	start := time.Now()
+	err := op()
+	options.Tracer.OnReadDir(dir, time.Since(start), err)
+	return err
+}
+
+// traceStat runs op reading the file info of path and reports it to options.Tracer
+func traceStat(options *Options, path string, op func() (os.FileInfo, error)) (os.FileInfo, error) {
+	if options.Tracer == nil {
+		return op()
+	}
+
+	start := time.Now()
+	info, err := op()
+	options.Tracer.OnStat(path, time.Since(start), err)
+	return info, err
+}
@@ -0,0 +1,106 @@
+package findup
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type traceEvent struct {
+	op   string
+	path string
+	ok   bool
+}
+
+// recordingTracer records the events it receives in order
+type recordingTracer struct {
+	mu     sync.Mutex
+	events []traceEvent
+}
+
+func (r *recordingTracer) OnReadDir(path string, dur time.Duration, err error) {
+	r.record("readdir", path, dur, err)
+}
+
+func (r *recordingTracer) OnStat(path string, dur time.Duration, err error) {
+	r.record("stat", path, dur, err)
+}
+
+func (r *recordingTracer) record(op, path string, dur time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, traceEvent{op: op, path: path, ok: err == nil && dur >= 0})
+}
+
+func TestTracer(t *testing.T) {
+	// tempDir/
+	//   ├── config.json
+	//   └── dir1/
+	//       ├── a.txt
+	//       └── dir2/
+	//           └── b.txt
+	tempDir := createTestTree(t, "config.json", "dir1/a.txt", "dir1/dir2/b.txt")
+	dir1 := filepath.Join(tempDir, "dir1")
+
+	t.Run("FindUp stats", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		options := &Options{Cwd: dir1, Tracer: tracer}
+		if _, err := FindUp("config.json", options); err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		expected := []traceEvent{
+			{"stat", filepath.Join(dir1, "config.json"), false},
+			{"stat", filepath.Join(tempDir, "config.json"), true},
+		}
+		if !reflect.DeepEqual(tracer.events, expected) {
+			t.Errorf("Expected %v, got %v", expected, tracer.events)
+		}
+	})
+
+	t.Run("FindDownMultiple listings", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		options := &Options{Cwd: dir1, Depth: -1, Tracer: tracer}
+		if _, err := FindDownMultiple("*.txt", options); err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		// Each directory is listed for its matches, then for its subdirectories
+		expected := []traceEvent{
+			{"readdir", dir1, true},
+			{"stat", filepath.Join(dir1, "a.txt"), true},
+			{"readdir", dir1, true},
+			{"readdir", filepath.Join(dir1, "dir2"), true},
+			{"stat", filepath.Join(dir1, "dir2", "b.txt"), true},
+			{"readdir", filepath.Join(dir1, "dir2"), true},
+		}
+		if !reflect.DeepEqual(tracer.events, expected) {
+			t.Errorf("Expected %v, got %v", expected, tracer.events)
+		}
+	})
+
+	t.Run("subdirectory and executable stats", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		options := &Options{Cwd: tempDir, Depth: -1, TraversalOrder: MTimeDesc, StayOnDevice: true, Tracer: tracer}
+		if _, err := FindDownMultiple("*.txt", options); err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if _, err := FindExecutable("config.json", &Options{Cwd: tempDir, Tracer: tracer}); err != nil {
+			t.Fatalf("FindExecutable failed: %v", err)
+		}
+
+		stats := map[string]int{}
+		for _, event := range tracer.events {
+			if event.op == "stat" {
+				stats[event.path]++
+			}
+		}
+		// dir2 is stated for its device and its modification time, config.json for its permission bits
+		if stats[filepath.Join(dir1, "dir2")] < 2 {
+			t.Errorf("Expected dir2 to be stated for StayOnDevice and TraversalOrder, got %v", tracer.events)
+		}
+		if stats[filepath.Join(tempDir, "config.json")] < 2 {
+			t.Errorf("Expected config.json to be stated by FindExecutable, got %v", tracer.events)
+		}
+	})
+}
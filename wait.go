@@ -0,0 +1,34 @@
+package findup
+
+import (
+	"context"
+	"time"
+)
+
+// defaultPollInterval is how often WaitForUp searches when no interval is given
+const defaultPollInterval = 100 * time.Millisecond
+
+// WaitForUp runs FindUp every pollInterval until it finds name, e.g. a marker written at the end of
+// a build, and returns the match. It returns the error of a failed search, or the context's error
+// once ctx is done. A pollInterval of 0 or less polls every 100 milliseconds.
+func WaitForUp(name string, options *Options, pollInterval time.Duration, ctx context.Context) (string, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := FindUp(name, options)
+		if err != nil || result != "" {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package findup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitForUp(t *testing.T) {
+	// tempDir/
+	//   ├── build.done   (created after a delay)
+	//   └── dir1/
+	tempDir := createTestTree(t, "dir1/")
+	cwd := filepath.Join(tempDir, "dir1")
+	marker := filepath.Join(tempDir, "build.done")
+
+	t.Run("marker appears", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			os.WriteFile(marker, []byte("done"), 0644)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		result, err := WaitForUp("build.done", options, 10*time.Millisecond, ctx)
+		if err != nil {
+			t.Fatalf("WaitForUp failed: %v", err)
+		}
+		if result != marker {
+			t.Errorf("Expected %s, got %s", marker, result)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		options := &Options{Cwd: cwd, StopAt: filepath.Dir(tempDir)}
+		result, err := WaitForUp("never.done", options, 10*time.Millisecond, ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected no match, got %s", result)
+		}
+	})
+}
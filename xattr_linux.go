@@ -0,0 +1,6 @@
+package findup
+
+import "golang.org/x/sys/unix"
+
+// errNoXattr is the error getxattr returns for an absent attribute
+const errNoXattr = unix.ENODATA
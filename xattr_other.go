@@ -0,0 +1,8 @@
+//go:build !(linux || darwin)
+
+package findup
+
+// xattrMatches never matches on platforms without extended attribute support
+func xattrMatches(path string, name string, value []byte) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,43 @@
+//go:build linux || darwin
+
+package findup
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrMatches reports whether path carries the extended attribute name, with value when it is non-nil
+func xattrMatches(path string, name string, value []byte) (bool, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil {
+		if isMissingXattr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if value == nil {
+		return true, nil
+	}
+	if size != len(value) {
+		return false, nil
+	}
+
+	data := make([]byte, size)
+	size, err = unix.Getxattr(path, name, data)
+	if err != nil {
+		if isMissingXattr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return bytes.Equal(data[:size], value), nil
+}
+
+// isMissingXattr reports whether err means the attribute is absent or unsupported by the filesystem
+func isMissingXattr(err error) bool {
+	return errors.Is(err, errNoXattr) || errors.Is(err, unix.ENOTSUP)
+}
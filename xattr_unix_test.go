@@ -0,0 +1,65 @@
+//go:build linux || darwin
+
+package findup
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestXattr(t *testing.T) {
+	// tempDir/
+	//   ├── tagged.txt   (user.findup.tag=blue)
+	//   ├── plain.txt
+	//   └── dir1/
+	//       └── tagged.txt
+	tempDir := createTestTree(t, "tagged.txt", "plain.txt", "dir1/tagged.txt")
+	cwd := filepath.Join(tempDir, "dir1")
+	tagged := filepath.Join(tempDir, "tagged.txt")
+
+	if err := unix.Setxattr(tagged, "user.findup.tag", []byte("blue"), 0); err != nil {
+		t.Skipf("Extended attributes not supported: %v", err)
+	}
+
+	t.Run("XattrName skips entries without the attribute", func(t *testing.T) {
+		result, err := FindUp("tagged.txt", &Options{Cwd: cwd, XattrName: "user.findup.tag"})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != tagged {
+			t.Errorf("Expected %s, got %s", tagged, result)
+		}
+	})
+
+	t.Run("XattrValue with matching value", func(t *testing.T) {
+		results, err := FindDownMultiple("*.txt", &Options{
+			Cwd:        tempDir,
+			Depth:      -1,
+			XattrName:  "user.findup.tag",
+			XattrValue: []byte("blue"),
+		})
+		if err != nil {
+			t.Fatalf("FindDownMultiple failed: %v", err)
+		}
+		if len(results) != 1 || results[0] != tagged {
+			t.Errorf("Expected [%s], got %v", tagged, results)
+		}
+	})
+
+	t.Run("XattrValue with different value", func(t *testing.T) {
+		result, err := FindUp("tagged.txt", &Options{
+			Cwd:        cwd,
+			StopAt:     filepath.Dir(tempDir),
+			XattrName:  "user.findup.tag",
+			XattrValue: []byte("red"),
+		})
+		if err != nil {
+			t.Fatalf("FindUp failed: %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got %s", result)
+		}
+	})
+}